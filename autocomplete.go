@@ -0,0 +1,50 @@
+package main
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/resolver"
+)
+
+// handleAutocomplete suggests known managers for the user options while typing.
+// For options with several users only the last, partially typed user is completed.
+func handleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	// Sub commands nest their options one level deeper
+	if len(options) == 1 && options[0].Type == discordgo.ApplicationCommandOptionSubCommand {
+		options = options[0].Options
+	}
+
+	var focused *discordgo.ApplicationCommandInteractionDataOption
+	for _, option := range options {
+		if option.Focused {
+			focused = option
+		}
+	}
+	if focused == nil {
+		return
+	}
+
+	location := guildLocation(i.GuildID, stringOption(optionMap(options), "location"))
+
+	// Keep everything before the last token and complete only the last one
+	input := focused.StringValue()
+	prefix, query := "", input
+	if focused.Name == "users" {
+		prefix, query = resolver.SplitLastToken(input)
+	}
+
+	managers, err := store.FindManagers(getBaseURL(location), query, resolver.MaxChoices)
+	if err != nil {
+		logger.WithError(err).Error("Finding managers for autocomplete failed")
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: resolver.Choices(prefix, managers),
+		},
+	})
+	if err != nil {
+		logger.WithError(err).Error("Error responding to autocomplete interaction")
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/formatutils"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/scheduler"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/storage"
+)
+
+// handleAutopost manages the automatic matchday posting of a guild with the set-channel, disable and status sub commands
+func handleAutopost(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondText(s, i, "Autopost is only available in servers")
+		return
+	}
+
+	subCommand := i.ApplicationCommandData().Options[0]
+	options := optionMap(subCommand.Options)
+
+	var config storage.AutopostConfig
+	var err error
+	switch subCommand.Name {
+	case "set-channel":
+		channelID := i.ChannelID
+		if option, ok := options["channel"]; ok {
+			channelID = option.ChannelValue(nil).ID
+		}
+		config, err = store.SetAutopostChannel(i.GuildID, channelID)
+	case "disable":
+		config, err = store.DisableAutopost(i.GuildID)
+	case "status":
+		config, err = store.AutopostConfig(i.GuildID)
+	}
+	if err != nil {
+		logger.WithError(err).Errorf("Error handling /autopost %s for guild %s", subCommand.Name, i.GuildID)
+		respondText(s, i, "Something went wrong while accessing the autopost settings")
+		return
+	}
+
+	respondText(s, i, formatAutopostConfig(config))
+}
+
+// formatAutopostConfig renders the autopost settings as a short message
+func formatAutopostConfig(config storage.AutopostConfig) string {
+	if !config.Enabled {
+		return "Autopost is disabled"
+	}
+	status := fmt.Sprintf("Autopost is enabled for <#%s>", config.ChannelID)
+	if !config.LastPostedAt.IsZero() {
+		status += fmt.Sprintf(", last posted matchday %d <t:%d:R>", config.LastMatchday, config.LastPostedAt.Unix())
+	}
+	return status
+}
+
+// postMatchday returns a scheduler.PostFunc that sends the results image to a channel
+func postMatchday(s *discordgo.Session) scheduler.PostFunc {
+	return func(ctx context.Context, guildID string, channelID string, results []parse.MatchResult) error {
+		results = formatutils.SortResults(results, logger)
+		imageBuf, err := renderResultsImage(ctx, results, resolveTheme(guildID, ""))
+		if err != nil {
+			return err
+		}
+
+		content := fmt.Sprintf("```Results of matchday %d```", maxMatchday(results))
+		_, err = s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+			Content: content,
+			Files: []*discordgo.File{
+				{
+					Name:   "SPOILER_results.png",
+					Reader: bytes.NewReader(imageBuf.Bytes()),
+				},
+			},
+		}, discordgo.WithContext(ctx))
+		return err
+	}
+}
+
+// maxMatchday returns the highest matchday of all results
+func maxMatchday(results []parse.MatchResult) int {
+	matchday := 0
+	for _, result := range results {
+		matchday = max(matchday, result.Matchday)
+	}
+	return matchday
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/formatutils"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/resolver"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/scraper"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+// Output formats of the headless results command in addition to the ones of /results
+const (
+	formatPNG  = "png"
+	formatJSON = "json"
+)
+
+// resultFormats are the output formats of the headless results command
+var resultFormats = []string{formatPNG, outputSVG, outputHTML, formatJSON, outputText}
+
+// cliUsage describes the headless subcommands
+const cliUsage = `Usage: ol_discord_bot [command] [flags]
+
+Without a command the discord bot is started.
+
+Commands:
+  results   Scrape the last match results of users and write them to a file or stdout
+`
+
+// runCLI runs a headless subcommand without connecting to discord and returns the exit code of the process
+func runCLI(args []string) int {
+	var err error
+	switch args[0] {
+	case "results":
+		err = runResults(args[1:], os.Stdout)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, cliUsage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", args[0], cliUsage)
+		return 2
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// cliResults is the JSON output of the results command
+type cliResults struct {
+	Results []parse.MatchResult `json:"results"`
+	Failed  []cliFailure        `json:"failed"`
+}
+
+// cliFailure is a user that could not be scraped
+type cliFailure struct {
+	UserID string `json:"userId"`
+	Reason string `json:"reason"`
+	Error  string `json:"error"`
+}
+
+// runResults scrapes the results of users and writes them as png, svg, html, json or text.
+// Only user ids are accepted since names and mentions are resolved with the bot's storage.
+func runResults(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("results", flag.ContinueOnError)
+	location := flags.String("location", defaultLocation, "The onlineliga location: .de, .co.uk, .at or .ch")
+	baseURL := flags.String("base-url", "", "Scrape this URL instead of the onlineliga of the location, e.g. a local fake API")
+	users := flags.String("users", "", "Space separated user ids (required)")
+	out := flags.String("out", "-", "The file to write to, - writes to stdout")
+	format := flags.String("format", formatPNG, "The output format: "+strings.Join(resultFormats, ", "))
+	theme := flags.String("theme", formatutils.DefaultThemeName, "The theme of png, svg and html output: "+strings.Join(formatutils.ThemeNames(), ", "))
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if !isLocation(*location) {
+		return fmt.Errorf("unknown location %q", *location)
+	}
+	if !slices.Contains(resultFormats, *format) {
+		return fmt.Errorf("unknown format %q, use one of %s", *format, strings.Join(resultFormats, ", "))
+	}
+	if _, ok := formatutils.Themes[*theme]; !ok {
+		return fmt.Errorf("unknown theme %q, use one of %s", *theme, strings.Join(formatutils.ThemeNames(), ", "))
+	}
+	userIDs := resolver.SplitTokens(*users)
+	if len(userIDs) == 0 {
+		return errors.New("no users given, use --users \"8315 7370\"")
+	}
+
+	// Stop scraping and downloading badges on CTRL-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	olScraper := scraper.NewScraper(logger, scraper.WithConcurrency(scrapeConcurrency))
+	if *baseURL == "" {
+		*baseURL = getBaseURL(*location)
+	}
+	results, failed := formatutils.SplitUserResults(olScraper.ScrapeMatchResultsContext(ctx, userIDs, *baseURL))
+	for _, userResult := range failed {
+		logger.WithError(userResult.Err).Errorf("Scraping match results for user %s failed", userResult.UserID)
+	}
+	if footer := formatutils.FailedUsersFooter(failed); footer != "" {
+		fmt.Fprintln(os.Stderr, footer)
+	}
+	if len(results) == 0 && *format != formatJSON {
+		return errors.New("no results could be loaded")
+	}
+	results = formatutils.SortResults(results, logger)
+
+	var output bytes.Buffer
+	var err error
+	config := imageConfig(formatutils.ThemeByName(*theme))
+	switch *format {
+	case formatJSON:
+		err = writeResultsJSON(&output, results, failed)
+	case outputText:
+		for _, block := range formatutils.MatchResultsToText(results, math.MaxInt) {
+			// The code block only makes sense in discord, a terminal shows the ANSI colours as they are
+			output.WriteString(strings.TrimSuffix(strings.TrimPrefix(block, "```ansi\n"), "```"))
+		}
+	case outputSVG:
+		output, err = formatutils.MatchResultsToSVG(results, config)
+	case outputHTML:
+		output, err = formatutils.MatchResultsToHTML(results, "Results "+*location, config)
+	default:
+		output, err = formatutils.MatchResultsToImage(ctx, results, config)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *out == "-" {
+		_, err = stdout.Write(output.Bytes())
+	} else {
+		err = os.WriteFile(*out, output.Bytes(), 0o644)
+	}
+	return err
+}
+
+// writeResultsJSON writes the results and the failed users as indented JSON
+func writeResultsJSON(w io.Writer, results []parse.MatchResult, failed []scraper.UserResult) error {
+	output := cliResults{Results: results, Failed: []cliFailure{}}
+	if output.Results == nil {
+		output.Results = []parse.MatchResult{}
+	}
+	for _, userResult := range failed {
+		output.Failed = append(output.Failed, cliFailure{
+			UserID: userResult.UserID,
+			Reason: scraper.ErrorReason(userResult.Err),
+			Error:  userResult.Err.Error(),
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/olfake"
+	"io"
+	"strings"
+	"testing"
+)
+
+func newTestServer(t *testing.T) *olfake.Server {
+	logger.SetOutput(io.Discard)
+	server := olfake.NewServer()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRunResultsJSON(t *testing.T) {
+	server := newTestServer(t)
+
+	var stdout bytes.Buffer
+	args := []string{"--base-url", server.URL(), "--users", olfake.DefaultUserID + " 404", "--format", formatJSON}
+	if err := runResults(args, &stdout); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var output cliResults
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+	if len(output.Results) != 1 || output.Results[0].HomeTeam != "Test A" || output.Results[0].MatchResult != "4 : 3" {
+		t.Errorf("Expected the result of user %s, got %v", olfake.DefaultUserID, output.Results)
+	}
+	expectedFailure := cliFailure{UserID: "404", Reason: "not found"}
+	if len(output.Failed) != 1 || output.Failed[0].UserID != expectedFailure.UserID || output.Failed[0].Reason != expectedFailure.Reason {
+		t.Errorf("Expected %v, got %v", expectedFailure, output.Failed)
+	}
+}
+
+func TestRunResultsText(t *testing.T) {
+	server := newTestServer(t)
+
+	var stdout bytes.Buffer
+	args := []string{"--base-url", server.URL(), "--users", olfake.DefaultUserID, "--format", outputText}
+	if err := runResults(args, &stdout); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The table is written without the code block around it
+	text := stdout.String()
+	if strings.Contains(text, "```") || !strings.Contains(text, "Test A") || !strings.Contains(text, "4 : 3") || !strings.Contains(text, "Test B") {
+		t.Errorf("Expected the result table of user %s, got %q", olfake.DefaultUserID, text)
+	}
+}
+
+func TestRunResultsErrors(t *testing.T) {
+	server := newTestServer(t)
+
+	var stdout bytes.Buffer
+	err := runResults([]string{"--location", ".fr", "--users", olfake.DefaultUserID}, &stdout)
+	if err == nil || !strings.Contains(err.Error(), `unknown location ".fr"`) {
+		t.Errorf("Expected an unknown location error, got %v", err)
+	}
+
+	// Without any result only json output is written
+	err = runResults([]string{"--base-url", server.URL(), "--users", "404", "--format", outputText}, &stdout)
+	if err == nil || stdout.Len() != 0 {
+		t.Errorf("Expected an error and no output, got %v and %q", err, stdout.String())
+	}
+	if server.OverviewRequests() != 1 {
+		t.Errorf("Expected %v, got %v", 1, server.OverviewRequests())
+	}
+}
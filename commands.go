@@ -0,0 +1,288 @@
+package main
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// locationChoices are the onlineliga instances a user can choose from
+var locationChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{
+		Name:  ".de",
+		Value: ".de",
+	},
+	{
+		Name:  ".co.uk",
+		Value: ".co.uk",
+	},
+	{
+		Name:  ".at",
+		Value: ".at",
+	},
+	{
+		Name:  ".ch",
+		Value: ".ch",
+	},
+}
+
+// manageGuildPermission restricts commands that change guild wide settings
+var manageGuildPermission int64 = discordgo.PermissionManageServer
+
+// commands are the application commands the bot registers for every guild or globally
+var commands = []*discordgo.ApplicationCommand{
+	{
+		Name:        "results",
+		Description: "Get the results of a user",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "location",
+				Choices:     locationChoices,
+				Description: "The location of the results, defaults to the location of the watchlist",
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "users",
+				Description:  "User ids, names or @mentions of linked users, defaults to the watchlist",
+				Autocomplete: true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "output",
+				Description: "How the results are shown, defaults to image",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  "image",
+						Value: outputImage,
+					},
+					{
+						Name:  "embed",
+						Value: outputEmbed,
+					},
+					{
+						Name:  "text",
+						Value: outputText,
+					},
+					{
+						Name:  "svg",
+						Value: outputSVG,
+					},
+					{
+						Name:  "html",
+						Value: outputHTML,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "theme",
+				Choices:     themeChoices(),
+				Description: "The theme of the image, defaults to the theme of this server",
+			},
+		},
+	},
+	{
+		Name:        "watch",
+		Description: "Manage the watchlist of this server",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Add users to the watchlist",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "users",
+						Description:  "The user ids or names to add",
+						Autocomplete: true,
+						Required:     true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "location",
+						Choices:     locationChoices,
+						Description: "The location of the watched users",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Remove users from the watchlist",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "users",
+						Description:  "The user ids or names to remove",
+						Autocomplete: true,
+						Required:     true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "Show the watchlist",
+			},
+		},
+	},
+	{
+		Name:                     "autopost",
+		Description:              "Post the results of the watchlist automatically after each matchday",
+		DefaultMemberPermissions: &manageGuildPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set-channel",
+				Description: "Enable autopost for a channel",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "The channel to post to, defaults to the current channel",
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "disable",
+				Description: "Disable autopost",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show the autopost settings",
+			},
+		},
+	},
+	{
+		Name:        "table",
+		Description: "Show the league table of a user",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "user",
+				Description:  "The user id or name whose league table is shown",
+				Autocomplete: true,
+				Required:     true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "location",
+				Choices:     locationChoices,
+				Description: "The location of the user, defaults to the location of the watchlist",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "theme",
+				Choices:     themeChoices(),
+				Description: "The theme of the image, defaults to the theme of this server",
+			},
+		},
+	},
+	{
+		Name:        "nextmatch",
+		Description: "Get the upcoming matches of users",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "users",
+				Description:  "User ids, names or @mentions of linked users, defaults to the watchlist",
+				Autocomplete: true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "location",
+				Choices:     locationChoices,
+				Description: "The location of the users, defaults to the location of the watchlist",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "theme",
+				Choices:     themeChoices(),
+				Description: "The theme of the image, defaults to the theme of this server",
+			},
+		},
+	},
+	{
+		Name:        "link",
+		Description: "Link your discord account to your onlineliga manager",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "location",
+				Choices:     locationChoices,
+				Description: "The location of your manager",
+				Required:    true,
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "user",
+				Description:  "The user id of your manager",
+				Autocomplete: true,
+				Required:     true,
+			},
+		},
+	},
+	{
+		Name:        "unlink",
+		Description: "Remove the link to your onlineliga manager",
+	},
+	{
+		Name:                     "theme",
+		Description:              "Configure the default image theme of this server",
+		DefaultMemberPermissions: &manageGuildPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Set the default theme",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Choices:     themeChoices(),
+						Description: "The theme",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Show the default theme",
+			},
+		},
+	},
+}
+
+// optionMap indexes command options by their name
+func optionMap(options []*discordgo.ApplicationCommandInteractionDataOption) map[string]*discordgo.ApplicationCommandInteractionDataOption {
+	optionsByName := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, option := range options {
+		optionsByName[option.Name] = option
+	}
+	return optionsByName
+}
+
+// stringOption returns the value of a string option or an empty string if it was not given
+func stringOption(options map[string]*discordgo.ApplicationCommandInteractionDataOption, name string) string {
+	if option, ok := options[name]; ok {
+		return option.StringValue()
+	}
+	return ""
+}
+
+// respondText answers an interaction with a plain text message
+func respondText(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+	if err != nil {
+		logger.WithError(err).Error("Error responding to interaction")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/formatutils"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/progress"
+	"time"
+)
+
+// progressInterval is the minimum time between two progress edits to stay clear of discord's rate limits
+const progressInterval = 1500 * time.Millisecond
+
+// deferResponse acknowledges an interaction so the bot has up to 15 minutes to edit in the actual answer
+func deferResponse(s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+}
+
+// editResponse replaces the content and the files of a deferred interaction response. Errors are logged and returned.
+func editResponse(s *discordgo.Session, i *discordgo.InteractionCreate, content string, files []*discordgo.File) error {
+	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:         &content,
+		Files:           files,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		logger.WithError(err).Error("Error responding to interaction")
+	}
+	return err
+}
+
+// withNotice appends a notice like an error message to the content of a response. The content is cut so both
+// fit into a single message.
+func withNotice(content string, notice string) string {
+	maxContentLength := formatutils.MaxMessageLength - len([]rune(notice)) - 1
+	if runes := []rune(content); len(runes) > maxContentLength {
+		content = string(runes[:maxContentLength-1]) + "…"
+	}
+	return content + "\n" + notice
+}
+
+// editResponseWithFiles edits the files into a deferred interaction response and falls back to an error message if the upload fails
+func editResponseWithFiles(s *discordgo.Session, i *discordgo.InteractionCreate, content string, files []*discordgo.File) {
+	if err := editResponse(s, i, content, files); err != nil {
+		_ = editResponse(s, i, withNotice(content, "The upload failed, please try again later."), nil)
+	}
+}
+
+// editResponseWithEmbeds edits the first page of embeds into a deferred interaction response and
+// sends all further pages as follow up messages
+func editResponseWithEmbeds(s *discordgo.Session, i *discordgo.InteractionCreate, content string, pages [][]*discordgo.MessageEmbed) {
+	if len(pages) == 0 {
+		_ = editResponse(s, i, content, nil)
+		return
+	}
+
+	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:         &content,
+		Embeds:          &pages[0],
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		logger.WithError(err).Error("Error responding to interaction with embeds")
+		_ = editResponse(s, i, withNotice(content, "The results could not be sent, please try again later."), nil)
+		return
+	}
+
+	for _, page := range pages[1:] {
+		_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Embeds:          page,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+		if err != nil {
+			logger.WithError(err).Error("Error sending follow up embeds")
+			return
+		}
+	}
+}
+
+// editResponseWithText edits the first text block below the content into a deferred interaction response and
+// sends all further blocks as follow up messages
+func editResponseWithText(s *discordgo.Session, i *discordgo.InteractionCreate, content string, blocks []string) {
+	if len(blocks) == 0 {
+		_ = editResponse(s, i, content, nil)
+		return
+	}
+
+	// The first block is sent with the content if both fit into one message, otherwise it follows separately
+	followUps := blocks
+	first := content
+	if len([]rune(content))+1+len([]rune(blocks[0])) <= formatutils.MaxMessageLength {
+		first, followUps = content+"\n"+blocks[0], blocks[1:]
+	}
+	if err := editResponse(s, i, first, nil); err != nil {
+		_ = editResponse(s, i, withNotice(content, "The results could not be sent, please try again later."), nil)
+		return
+	}
+
+	for _, block := range followUps {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content:         block,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+		if err != nil {
+			logger.WithError(err).Error("Error sending follow up text")
+			return
+		}
+	}
+}
+
+// newProgressReporter returns a progress.Reporter that edits the progress below header into a deferred
+// interaction response
+func newProgressReporter(s *discordgo.Session, i *discordgo.InteractionCreate, header string) *progress.Reporter {
+	return progress.NewReporter(func(content string) {
+		_ = editResponse(s, i, content, nil)
+	}, header, progressInterval)
+}
@@ -0,0 +1,217 @@
+package badges
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// maxBadgeSize is the maximum size of a badge download, badges are small icons
+const maxBadgeSize = 1 << 20
+
+// DefaultPrefetchConcurrency is the number of badges that are downloaded in parallel by LoadAll
+const DefaultPrefetchConcurrency = 8
+
+// Cache loads badge images by URL and keeps the decoded images in an in-memory LRU.
+// If a directory is configured, the raw downloads are additionally kept on disk across restarts.
+type Cache struct {
+	client   *http.Client
+	capacity int
+	dir      string
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// entry is an element of the LRU list
+type entry struct {
+	url   string
+	image image.Image
+}
+
+// NewCache returns a new Cache that keeps up to capacity decoded badges in memory.
+// An empty dir disables the on-disk cache.
+func NewCache(client *http.Client, capacity int, dir string) *Cache {
+	return &Cache{
+		client:   client,
+		capacity: max(capacity, 1),
+		dir:      dir,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns the badge behind url from memory, from disk or by downloading it
+func (c *Cache) Get(ctx context.Context, url string) (image.Image, error) {
+	if img, ok := c.fromMemory(url); ok {
+		return img, nil
+	}
+
+	if data, err := c.fromDisk(url); err == nil {
+		if img, _, decodeErr := image.Decode(bytes.NewReader(data)); decodeErr == nil {
+			c.toMemory(url, img)
+			return img, nil
+		}
+		// A corrupt or truncated file would fail forever, it is replaced by a new download
+		_ = os.Remove(c.diskPath(url))
+	}
+
+	data, err := c.download(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	c.toDisk(url, data)
+	c.toMemory(url, img)
+	return img, nil
+}
+
+// LoadAll loads all badges in parallel and returns the ones that could be loaded keyed by URL.
+// Duplicate and empty URLs are loaded only once or skipped. Once ctx is done no further downloads are started.
+func (c *Cache) LoadAll(ctx context.Context, urls []string) map[string]image.Image {
+	unique := make(map[string]struct{}, len(urls))
+	for _, url := range urls {
+		if url != "" {
+			unique[url] = struct{}{}
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	images := make(map[string]image.Image, len(unique))
+	semaphore := make(chan struct{}, DefaultPrefetchConcurrency)
+prefetch:
+	for url := range unique {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			break prefetch
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			img, err := c.Get(ctx, url)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			images[url] = img
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return images
+}
+
+// fromMemory returns a decoded badge and marks it as recently used
+func (c *Cache) fromMemory(url string) (image.Image, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[url]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*entry).image, true
+}
+
+// toMemory stores a decoded badge and evicts the least recently used one if the cache is full
+func (c *Cache) toMemory(url string, img image.Image) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[url]; ok {
+		element.Value.(*entry).image = img
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[url] = c.order.PushFront(&entry{url: url, image: img})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).url)
+	}
+}
+
+// diskPath returns the file a badge is stored in on disk
+func (c *Cache) diskPath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+// fromDisk reads the raw badge from the on-disk cache
+func (c *Cache) fromDisk(url string) ([]byte, error) {
+	if c.dir == "" {
+		return nil, os.ErrNotExist
+	}
+	return os.ReadFile(c.diskPath(url))
+}
+
+// toDisk writes the raw badge to the on-disk cache. Failures only cost a download later and are ignored.
+func (c *Cache) toDisk(url string, data []byte) {
+	if c.dir == "" {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return
+	}
+	// Write to a temporary file first so concurrent readers never see a partial badge
+	tmp, err := os.CreateTemp(c.dir, "badge-*")
+	if err != nil {
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil {
+		_ = os.Remove(tmp.Name())
+		return
+	}
+	if err = os.Rename(tmp.Name(), c.diskPath(url)); err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+// download fetches the raw badge with the shared http client, badges larger than maxBadgeSize fail
+func (c *Cache) download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading badge %s failed with status %s", url, resp.Status)
+	}
+	// Read one byte more than allowed to tell a badge of exactly maxBadgeSize from a larger one
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBadgeSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBadgeSize {
+		return nil, fmt.Errorf("badge %s is larger than %d bytes", url, maxBadgeSize)
+	}
+	return data, nil
+}
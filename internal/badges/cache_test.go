@@ -0,0 +1,122 @@
+package badges_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/badges"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	var badgePNG bytes.Buffer
+	if err := png.Encode(&badgePNG, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("Failed to encode badge: %v", err)
+	}
+
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.png" {
+			http.NotFound(w, r)
+			return
+		}
+		downloads.Add(1)
+		_, _ = w.Write(badgePNG.Bytes())
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	cache := badges.NewCache(server.Client(), 1, dir)
+	urls := []string{server.URL + "/1.png", server.URL + "/2.png", server.URL + "/1.png", server.URL + "/missing.png", ""}
+
+	ctx := context.Background()
+	images := cache.LoadAll(ctx, urls)
+	if len(images) != 2 || downloads.Load() != 2 {
+		t.Fatalf("Expected 2 badges from 2 downloads, got %d badges from %d downloads", len(images), downloads.Load())
+	}
+
+	// Only one badge fits into memory, the evicted one is read from disk instead of downloaded again
+	for _, url := range urls[:2] {
+		if _, err := cache.Get(ctx, url); err != nil {
+			t.Errorf("Failed to get %s: %v", url, err)
+		}
+	}
+	fresh := badges.NewCache(server.Client(), 1, dir)
+	if _, err := fresh.Get(ctx, urls[0]); err != nil {
+		t.Errorf("Failed to get %s from disk: %v", urls[0], err)
+	}
+	if downloads.Load() != 2 {
+		t.Errorf("Expected no further downloads, got %d", downloads.Load())
+	}
+
+	if _, err := cache.Get(ctx, urls[3]); err == nil {
+		t.Error("Expected an error for a missing badge")
+	}
+
+	// Nothing is downloaded once the context is done
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if images := cache.LoadAll(cancelled, []string{server.URL + "/3.png"}); len(images) != 0 || downloads.Load() != 2 {
+		t.Errorf("Expected no badges from no further downloads, got %d badges from %d downloads", len(images), downloads.Load())
+	}
+}
+
+func TestCacheCorruptFile(t *testing.T) {
+	var badgePNG bytes.Buffer
+	if err := png.Encode(&badgePNG, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("Failed to encode badge: %v", err)
+	}
+
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		if r.URL.Path == "/huge.png" {
+			_, _ = w.Write(make([]byte, 2<<20))
+			return
+		}
+		_, _ = w.Write(badgePNG.Bytes())
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	ctx := context.Background()
+	url := server.URL + "/1.png"
+	if _, err := badges.NewCache(server.Client(), 1, dir).Get(ctx, url); err != nil {
+		t.Fatalf("Failed to get %s: %v", url, err)
+	}
+
+	// A truncated file on disk is downloaded again and replaced
+	files, err := os.ReadDir(dir)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Expected 1 cached file, got %d (%v)", len(files), err)
+	}
+	path := filepath.Join(dir, files[0].Name())
+	if err = os.WriteFile(path, badgePNG.Bytes()[:badgePNG.Len()/2], 0o644); err != nil {
+		t.Fatalf("Failed to truncate the cached file: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err = badges.NewCache(server.Client(), 1, dir).Get(ctx, url); err != nil {
+			t.Errorf("Failed to get %s: %v", url, err)
+		}
+	}
+	if downloads.Load() != 2 {
+		t.Errorf("Expected the corrupt badge to be downloaded once more, got %d downloads", downloads.Load())
+	}
+	if data, readErr := os.ReadFile(path); readErr != nil || !bytes.Equal(data, badgePNG.Bytes()) {
+		t.Errorf("Expected the cached file to be replaced, got %d bytes (%v)", len(data), readErr)
+	}
+
+	// Oversized badges fail instead of being cut off and cached
+	if _, err = badges.NewCache(server.Client(), 1, dir).Get(ctx, server.URL+"/huge.png"); err == nil {
+		t.Error("Expected an error for an oversized badge")
+	}
+	if files, err = os.ReadDir(dir); err != nil || len(files) != 1 {
+		t.Errorf("Expected only 1 cached file, got %d (%v)", len(files), err)
+	}
+}
@@ -0,0 +1,141 @@
+package fonts
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"os"
+)
+
+// defaultFontData is the font that is always available as the last resort of every chain
+//
+//go:embed files/Go-Mono-Bold.ttf
+var defaultFontData []byte
+
+// defaultFont is the parsed default font, it is parsed once at startup
+var defaultFont = mustParse(defaultFontData)
+
+func mustParse(data []byte) *truetype.Font {
+	parsed, err := truetype.Parse(data)
+	if err != nil {
+		panic(fmt.Sprintf("parsing the embedded default font failed: %s", err))
+	}
+	return parsed
+}
+
+// Chain is an ordered list of parsed fonts. Every glyph is drawn with the first font that contains it,
+// so fonts for emoji or CJK team names can back up the primary font. The embedded default font is always last.
+type Chain struct {
+	fonts []*truetype.Font
+}
+
+// Default returns a chain that only consists of the embedded default font
+func Default() *Chain {
+	return &Chain{fonts: []*truetype.Font{defaultFont}}
+}
+
+// Load parses the font files in the given order and appends the embedded default font.
+// Files that cannot be loaded are skipped and reported in the returned error, the chain is usable regardless.
+func Load(paths ...string) (*Chain, error) {
+	chain := &Chain{}
+	var errs []error
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		parsed, err := parseFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		chain.fonts = append(chain.fonts, parsed)
+	}
+	chain.fonts = append(chain.fonts, defaultFont)
+	return chain, errors.Join(errs...)
+}
+
+// WithPrimary returns a copy of the chain with the font file at path in front
+func (c *Chain) WithPrimary(path string) (*Chain, error) {
+	parsed, err := parseFile(path)
+	if err != nil {
+		return c, err
+	}
+	return &Chain{fonts: append([]*truetype.Font{parsed}, c.fonts...)}, nil
+}
+
+// parseFile reads and parses a TrueType font file
+func parseFile(path string) (*truetype.Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading font %s failed: %w", path, err)
+	}
+	parsed, err := truetype.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing font %s failed: %w", path, err)
+	}
+	return parsed, nil
+}
+
+// Face returns a new font face of the given size. Faces cache glyphs and must not be shared between goroutines,
+// so every drawing context should get its own face.
+func (c *Chain) Face(size float64) font.Face {
+	faces := make([]font.Face, len(c.fonts))
+	for i, parsed := range c.fonts {
+		faces[i] = truetype.NewFace(parsed, &truetype.Options{Size: size})
+	}
+	return &fallbackFace{fonts: c.fonts, faces: faces}
+}
+
+// fallbackFace implements font.Face by asking the first face whose font contains the requested glyph
+type fallbackFace struct {
+	fonts []*truetype.Font
+	faces []font.Face
+}
+
+// faceFor returns the first face that can draw r, or the primary face if none can
+func (f *fallbackFace) faceFor(r rune) font.Face {
+	for i, parsed := range f.fonts {
+		if parsed.Index(r) != 0 {
+			return f.faces[i]
+		}
+	}
+	return f.faces[0]
+}
+
+func (f *fallbackFace) Close() error {
+	var errs []error
+	for _, face := range f.faces {
+		errs = append(errs, face.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (f *fallbackFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	return f.faceFor(r).Glyph(dot, r)
+}
+
+func (f *fallbackFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	return f.faceFor(r).GlyphBounds(r)
+}
+
+func (f *fallbackFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	return f.faceFor(r).GlyphAdvance(r)
+}
+
+// Kern only kerns glyphs of the same font, kerning across fonts is meaningless
+func (f *fallbackFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	face0, face1 := f.faceFor(r0), f.faceFor(r1)
+	if face0 != face1 {
+		return 0
+	}
+	return face0.Kern(r0, r1)
+}
+
+// Metrics are those of the primary font so line heights stay stable across rows
+func (f *fallbackFace) Metrics() font.Metrics {
+	return f.faces[0].Metrics()
+}
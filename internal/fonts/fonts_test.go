@@ -0,0 +1,24 @@
+package fonts_test
+
+import (
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/fonts"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	// A missing font is reported but the chain still falls back to the embedded font
+	chain, err := fonts.Load("", "does/not/exist.ttf")
+	if err == nil {
+		t.Error("Expected an error for a missing font")
+	}
+
+	face := chain.Face(14)
+	defer face.Close()
+	advance, ok := face.GlyphAdvance('A')
+	if !ok || advance <= 0 {
+		t.Errorf("Expected a positive advance for 'A', got %v (%v)", advance, ok)
+	}
+	if _, err = chain.WithPrimary("does/not/exist.ttf"); err == nil {
+		t.Error("Expected an error for a missing primary font")
+	}
+}
@@ -0,0 +1,85 @@
+package formatutils
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+)
+
+// Discord limits for embeds, see https://discord.com/developers/docs/resources/message#embed-object-embed-limits
+const (
+	maxEmbedsPerMessage   = 10
+	maxEmbedCharsPerMsg   = 6000
+	maxEmbedTitleLength   = 256
+	maxEmbedFieldValueLen = 1024
+)
+
+// MatchResultsToEmbeds converts match results to discord embeds, one embed per result. The embeds are
+// split into pages that each fit into a single message with respect to discord's count and size limits.
+func MatchResultsToEmbeds(results []parse.MatchResult) [][]*discordgo.MessageEmbed {
+	var pages [][]*discordgo.MessageEmbed
+	var page []*discordgo.MessageEmbed
+	pageChars := 0
+	for _, result := range results {
+		embed := matchResultToEmbed(result)
+		embedChars := embedLength(embed)
+		if len(page) == maxEmbedsPerMessage || pageChars+embedChars > maxEmbedCharsPerMsg {
+			pages = append(pages, page)
+			page, pageChars = nil, 0
+		}
+		page = append(page, embed)
+		pageChars += embedChars
+	}
+	if len(page) > 0 {
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// matchResultToEmbed converts a single match result to an embed coloured by the match state
+func matchResultToEmbed(result parse.MatchResult) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title: truncateRunes(result.HomeTeam+"  "+result.MatchResult+"  "+result.AwayTeam, maxEmbedTitleLength),
+		Color: embedStateColor(result.MatchState),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "League", Value: truncateRunes(result.LeagueLevel, maxEmbedFieldValueLen), Inline: true},
+			{Name: "Position", Value: truncateRunes(result.LeaguePosition, maxEmbedFieldValueLen), Inline: true},
+			{Name: "Points", Value: truncateRunes(result.Points, maxEmbedFieldValueLen), Inline: true},
+		},
+	}
+	if isImageURL(result.BadgeURL) {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: result.BadgeURL}
+	}
+	return embed
+}
+
+// embedStateColor returns the embed colour of a match state, matching the colours of the dark theme
+func embedStateColor(matchState string) int {
+	switch matchState {
+	case "WIN":
+		return 0x00ff00
+	case "LOSS":
+		return 0xff0000
+	case "DRAW":
+		return 0xffff00
+	default:
+		return 0xffffff
+	}
+}
+
+// embedLength counts the characters of an embed the way discord does for the 6000 characters limit
+func embedLength(embed *discordgo.MessageEmbed) int {
+	length := len([]rune(embed.Title)) + len([]rune(embed.Description))
+	for _, field := range embed.Fields {
+		length += len([]rune(field.Name)) + len([]rune(field.Value))
+	}
+	return length
+}
+
+// truncateRunes shortens text to at most limit characters and marks the cut with an ellipsis
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,81 @@
+package formatutils_test
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/formatutils"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"strings"
+	"testing"
+)
+
+// embedChars counts the characters of an embed like discord does for its 6000 characters limit
+func embedChars(embed *discordgo.MessageEmbed) int {
+	length := len([]rune(embed.Title)) + len([]rune(embed.Description))
+	for _, field := range embed.Fields {
+		length += len([]rune(field.Name)) + len([]rune(field.Value))
+	}
+	return length
+}
+
+// checkEmbedPages checks that the pages hold one embed per result in order and stay within discord's limits
+func checkEmbedPages(t *testing.T, pages [][]*discordgo.MessageEmbed, results []parse.MatchResult, expectedSizes []int) {
+	t.Helper()
+	sizes := make([]int, 0, len(pages))
+	var titles []string
+	for _, page := range pages {
+		sizes = append(sizes, len(page))
+		pageChars := 0
+		for _, embed := range page {
+			pageChars += embedChars(embed)
+			titles = append(titles, embed.Title)
+		}
+		if len(page) > 10 || pageChars > 6000 {
+			t.Errorf("Expected at most 10 embeds and 6000 characters per page, got %d embeds and %d characters", len(page), pageChars)
+		}
+	}
+	if fmt.Sprint(sizes) != fmt.Sprint(expectedSizes) {
+		t.Errorf("Expected pages of %v, got %v", expectedSizes, sizes)
+	}
+	for index, result := range results {
+		if index >= len(titles) || !strings.HasPrefix(titles[index], result.HomeTeam+" ") {
+			t.Errorf("Expected embed %d to show %s, got %v", index, result.HomeTeam, titles)
+			return
+		}
+	}
+}
+
+func TestMatchResultsToEmbedsManyResults(t *testing.T) {
+	results := make([]parse.MatchResult, 26)
+	for index := range results {
+		results[index] = parse.MatchResult{
+			LeagueLevel: "OL1", LeaguePosition: "#1", HomeTeam: fmt.Sprintf("Team %d", index), MatchResult: "1 : 0",
+			MatchState: "WIN", AwayTeam: "Test B", Points: "3 pts",
+		}
+	}
+
+	// More than 25 results need a third message, each one holds at most 10 embeds
+	checkEmbedPages(t, formatutils.MatchResultsToEmbeds(results), results, []int{10, 10, 6})
+}
+
+func TestMatchResultsToEmbedsCharacterLimit(t *testing.T) {
+	results := make([]parse.MatchResult, 12)
+	for index := range results {
+		results[index] = parse.MatchResult{
+			LeagueLevel: "OL1", LeaguePosition: "#1", HomeTeam: fmt.Sprintf("Team %d", index), MatchResult: "1 : 0",
+			MatchState: "WIN", AwayTeam: strings.Repeat("ü", 300), Points: strings.Repeat("ö", 1200),
+		}
+	}
+
+	// Titles and field values are cut to discord's limits, which makes every embed about 1300 characters long,
+	// so only 4 of them fit into the 6000 characters of a message
+	pages := formatutils.MatchResultsToEmbeds(results)
+	embed := pages[0][0]
+	if length := len([]rune(embed.Title)); length != 256 || !strings.HasSuffix(embed.Title, "…") {
+		t.Errorf("Expected a title of 256 characters ending with an ellipsis, got %d characters", length)
+	}
+	if length := len([]rune(embed.Fields[2].Value)); length != 1024 {
+		t.Errorf("Expected %v, got %v", 1024, length)
+	}
+	checkEmbedPages(t, pages, results, []int{4, 4, 4})
+}
@@ -0,0 +1,65 @@
+package formatutils
+
+import (
+	"fmt"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/scraper"
+	"strings"
+)
+
+// SplitUserResults separates successful match results from the failed ones while keeping their order
+func SplitUserResults(userResults []scraper.UserResult) ([]parse.MatchResult, []scraper.UserResult) {
+	var results []parse.MatchResult
+	var failed []scraper.UserResult
+	for _, userResult := range userResults {
+		if userResult.Err != nil {
+			failed = append(failed, userResult)
+			continue
+		}
+		results = append(results, userResult.Result)
+	}
+	return results, failed
+}
+
+// Limits of FailedUsersFooter, so the footer leaves room for the rest of a message
+const (
+	maxFooterEntries  = 10
+	maxFooterLength   = 600
+	maxFooterIDLength = 40
+)
+
+// FailedUsersFooter summarises the users that could not be loaded, e.g.
+// "could not load: 1234 (not found), abc (invalid ID)". It returns an empty string if nothing failed.
+// At most maxFooterEntries users are listed within maxFooterLength characters, the rest is counted, e.g. "… and 3 more".
+func FailedUsersFooter(failed []scraper.UserResult) string {
+	if len(failed) == 0 {
+		return ""
+	}
+	// Every entry that is not listed leaves room for ", … and 1000 more"
+	const moreLength = 20
+	var footer strings.Builder
+	footer.WriteString("could not load: ")
+	footerLength := footer.Len()
+	listed := 0
+	for _, userResult := range failed {
+		entry := fmt.Sprintf("%s (%s)", truncateRunes(userResult.UserID, maxFooterIDLength), scraper.ErrorReason(userResult.Err))
+		if listed > 0 {
+			entry = ", " + entry
+		}
+		limit := maxFooterLength
+		if listed+1 < len(failed) {
+			limit -= moreLength
+		}
+		entryLength := len([]rune(entry))
+		if listed == maxFooterEntries || footerLength+entryLength > limit {
+			break
+		}
+		footer.WriteString(entry)
+		footerLength += entryLength
+		listed++
+	}
+	if more := len(failed) - listed; more > 0 {
+		fmt.Fprintf(&footer, ", … and %d more", more)
+	}
+	return footer.String()
+}
@@ -0,0 +1,80 @@
+package formatutils_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/formatutils"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/scraper"
+	"strings"
+	"testing"
+)
+
+func TestSplitUserResults(t *testing.T) {
+	userResults := []scraper.UserResult{
+		{UserID: "1", Result: parse.MatchResult{HomeTeam: "Test A"}},
+		{UserID: "2", Err: errors.New("connection refused")},
+		{UserID: "3", Result: parse.MatchResult{HomeTeam: "Test B"}},
+		{UserID: "4", Err: context.DeadlineExceeded},
+	}
+
+	results, failed := formatutils.SplitUserResults(userResults)
+	if len(results) != 2 || results[0].HomeTeam != "Test A" || results[1].HomeTeam != "Test B" {
+		t.Errorf("Expected the results of Test A and Test B, got %v", results)
+	}
+	if len(failed) != 2 || failed[0].UserID != "2" || failed[1].UserID != "4" {
+		t.Errorf("Expected users 2 and 4 to fail, got %v", failed)
+	}
+
+	results, failed = formatutils.SplitUserResults(nil)
+	if len(results) != 0 || len(failed) != 0 {
+		t.Errorf("Expected nothing, got %v and %v", results, failed)
+	}
+}
+
+func TestFailedUsersFooter(t *testing.T) {
+	if footer := formatutils.FailedUsersFooter(nil); footer != "" {
+		t.Errorf("Expected no footer, got %q", footer)
+	}
+
+	failed := []scraper.UserResult{
+		{UserID: "1234", Err: &scraper.NotFoundError{}},
+		{UserID: "abc", Err: &scraper.InvalidUserIDError{}},
+		{UserID: "5678", Err: context.DeadlineExceeded},
+	}
+	expected := "could not load: 1234 (not found), abc (invalid ID), 5678 (timed out)"
+	if footer := formatutils.FailedUsersFooter(failed); footer != expected {
+		t.Errorf("Expected %q, got %q", expected, footer)
+	}
+}
+
+func TestFailedUsersFooterLimit(t *testing.T) {
+	tests := map[string]struct {
+		userID       string
+		count        int
+		expectedMore int
+	}{
+		"many users":         {userID: "1234", count: 200, expectedMore: 190},
+		"long tokens":        {userID: strings.Repeat("ö", 300), count: 30, expectedMore: 20},
+		"just enough tokens": {userID: "1234", count: 10, expectedMore: 0},
+	}
+	for name, test := range tests {
+		failed := make([]scraper.UserResult, test.count)
+		for index := range failed {
+			failed[index] = scraper.UserResult{UserID: test.userID, Err: &scraper.NotFoundError{}}
+		}
+
+		footer := formatutils.FailedUsersFooter(failed)
+		if length := len([]rune(footer)); length > 600 {
+			t.Errorf("%s: Expected at most 600 characters, got %d", name, length)
+		}
+		more := fmt.Sprintf("… and %d more", test.expectedMore)
+		if test.expectedMore > 0 && !strings.HasSuffix(footer, more) {
+			t.Errorf("%s: Expected the footer to end with %q, got %q", name, more, footer)
+		}
+		if test.expectedMore == 0 && strings.Contains(footer, "more") {
+			t.Errorf("%s: Expected all users to be listed, got %q", name, footer)
+		}
+	}
+}
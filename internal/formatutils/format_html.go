@@ -0,0 +1,35 @@
+package formatutils
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"html"
+)
+
+// MatchResultsToHTML converts match results to a standalone HTML page. The page embeds the SVG of
+// MatchResultsToSVG, so it scales and looks the same as the image.
+func MatchResultsToHTML(results []parse.MatchResult, title string, config ImageConfig) (bytes.Buffer, error) {
+	layout, err := matchResultsLayout(results, config)
+	if err != nil {
+		return bytes.Buffer{}, err
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>%s</title>
+<style>
+body { margin: 0; background: %s; }
+svg { display: block; max-width: 100%%; height: auto; margin: 0 auto; }
+</style>
+</head>
+<body>
+`, html.EscapeString(title), config.theme().Background.css())
+	writeMatchResultsSVG(buf, layout, results)
+	buf.WriteString("</body>\n</html>\n")
+	return *buf, nil
+}
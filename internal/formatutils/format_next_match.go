@@ -0,0 +1,97 @@
+package formatutils
+
+import (
+	"bytes"
+	"context"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"time"
+)
+
+// UpcomingMatchesToImage converts upcoming matches to an image with one row per match. Badge downloads are
+// cancelled with ctx.
+func UpcomingMatchesToImage(ctx context.Context, matches []parse.UpcomingMatch, config ImageConfig) (bytes.Buffer, error) {
+	if len(matches) == 0 {
+		return bytes.Buffer{}, &FormatError{Msg: "Error: There are no upcoming matches"}
+	}
+
+	layout, err := upcomingMatchesLayout(matches, config)
+	if err != nil {
+		return bytes.Buffer{}, err
+	}
+	dc, err := layout.newContext()
+	if err != nil {
+		return bytes.Buffer{}, err
+	}
+
+	// Download all badges in parallel before drawing
+	badgeURLs := make([]string, 0, 2*len(matches))
+	for _, match := range matches {
+		badgeURLs = append(badgeURLs, match.BadgeURL, match.OpponentBadgeURL)
+	}
+	badgeImages := badgeCache(config).LoadAll(ctx, badgeURLs)
+	if err := ctx.Err(); err != nil {
+		return bytes.Buffer{}, err
+	}
+
+	theme := config.theme()
+	layout.drawHeader(dc)
+	for rowIndex := 1; rowIndex <= len(matches); rowIndex++ {
+		drawRowBackground(dc, theme, rowIndex, layout.rowTop(rowIndex), config)
+		layout.drawRow(dc, rowIndex, badgeImages, nil)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := encodeToPNG(dc, buf); err != nil {
+		return *buf, err
+	}
+	return *buf, nil
+}
+
+// upcomingMatchColumns returns the columns of an upcoming matches image
+func upcomingMatchColumns(config ImageConfig) []column {
+	return []column{
+		{title: "OL", align: alignCenter},
+		{badge: true},
+		{title: "Team", align: alignRight, maxWidth: config.maxTeamWidth()},
+		{title: "", align: alignCenter},
+		{badge: true},
+		{title: "Opponent", align: alignLeft, maxWidth: config.maxTeamWidth()},
+		{title: "Competition", align: alignLeft},
+		{title: "Kickoff", align: alignLeft},
+	}
+}
+
+// upcomingMatchesLayout measures the upcoming matches table
+func upcomingMatchesLayout(matches []parse.UpcomingMatch, config ImageConfig) (tableLayout, error) {
+	rows := make([][]string, 0, len(matches))
+	for _, match := range matches {
+		rows = append(rows, upcomingMatchRow(match))
+	}
+	return measureTable(upcomingMatchColumns(config), rows, config)
+}
+
+// upcomingMatchRow returns the cells of a single upcoming match row
+func upcomingMatchRow(match parse.UpcomingMatch) []string {
+	venue := "vs"
+	if !match.Home {
+		venue = "@"
+	}
+	return []string{
+		match.LeagueLevel,
+		match.BadgeURL,
+		match.Team,
+		venue,
+		match.OpponentBadgeURL,
+		match.Opponent,
+		match.Competition,
+		FormatKickoff(match.Kickoff),
+	}
+}
+
+// FormatKickoff formats a kickoff time in german time, unknown kickoff times are shown as "tba"
+func FormatKickoff(kickoff time.Time) string {
+	if kickoff.IsZero() {
+		return "tba"
+	}
+	return kickoff.In(parse.KickoffLocation).Format("Mon 02.01. 15:04")
+}
@@ -2,41 +2,80 @@ package formatutils
 
 import (
 	"bytes"
+	"context"
 	"github.com/fogleman/gg"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/badges"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/fonts"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/httpclient"
 	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
 	"image"
 	"image/png"
-	"net/http"
-	"os"
 	"strings"
+	"sync"
 )
 
+// defaultFontChain is used by all renderers whose config does not bring its own fonts
+var defaultFontChain = fonts.Default()
+
+// defaultBadgeCache is used by all renderers whose config does not bring its own badge cache
+var defaultBadgeCache = badges.NewCache(httpclient.DefaultHTTPClient, 512, "")
+
 type ImageConfig struct {
-	FontSize    float64
-	Margin      float64
-	ColWidth    float64
-	RowHeight   float64
-	BadeWidth   float64
-	BadgeHeight float64
-	R           float64
-	G           float64
-	B           float64
-}
-
-// MatchResultsToImage converts match results to an image
-func MatchResultsToImage(results []parse.MatchResult, config ImageConfig) (bytes.Buffer, error) {
-	width, height := calculateImageSize(results, config)
-	dc := createNewContext(int(width), int(height))
-
-	if err := loadFontFace(dc, config.FontSize); err != nil {
+	// Badges loads the badge images, the shared in-memory cache is used if it is nil
+	Badges *badges.Cache
+	// Fonts draws the text, the embedded default font is used if it is nil
+	Fonts     *fonts.Chain
+	FontSize  float64
+	Margin    float64
+	RowHeight float64
+	// MaxTeamWidth is the width in pixels after which team names are shortened with an ellipsis
+	MaxTeamWidth float64
+	BadeWidth    float64
+	BadgeHeight  float64
+	// Theme sets colours and font, the default theme is used if it is empty
+	Theme Theme
+}
+
+// resultColumn is the index of the result column of a match results image
+const resultColumn = 4
+
+// matchResultColumns returns the columns of a match results image
+func matchResultColumns(config ImageConfig) []column {
+	return []column{
+		{title: "OL", align: alignCenter},
+		{badge: true},
+		{title: "#", align: alignRight},
+		{title: "Home", align: alignRight, maxWidth: config.maxTeamWidth()},
+		{title: "Result", align: alignCenter},
+		{title: "Away", align: alignLeft, maxWidth: config.maxTeamWidth()},
+		{title: "Pts", align: alignRight},
+	}
+}
+
+// MatchResultsToImage converts match results to an image. Badge downloads are cancelled with ctx.
+func MatchResultsToImage(ctx context.Context, results []parse.MatchResult, config ImageConfig) (bytes.Buffer, error) {
+	layout, err := matchResultsLayout(results, config)
+	if err != nil {
+		return bytes.Buffer{}, err
+	}
+	dc, err := layout.newContext()
+	if err != nil {
 		return bytes.Buffer{}, err
 	}
 
-	dc.SetRGB(config.R, config.G, config.B)
-	config.ColWidth = width / 7
+	// Download all badges in parallel before drawing
+	badgeURLs := make([]string, 0, len(results))
+	for _, result := range results {
+		badgeURLs = append(badgeURLs, result.BadgeURL)
+	}
+	badgeImages := badgeCache(config).LoadAll(ctx, badgeURLs)
+	if err := ctx.Err(); err != nil {
+		return bytes.Buffer{}, err
+	}
 
+	layout.drawHeader(dc)
 	for i, result := range results {
-		writeMatchResult(dc, result, config, i)
+		writeMatchResult(dc, layout, result, badgeImages, i+1)
 	}
 
 	buf := new(bytes.Buffer)
@@ -46,37 +85,35 @@ func MatchResultsToImage(results []parse.MatchResult, config ImageConfig) (bytes
 	return *buf, nil
 }
 
-// writeMatchResult writes a single match result to the image
-func writeMatchResult(dc *gg.Context, result parse.MatchResult, config ImageConfig, rowIndex int) {
-	fields := []string{
-		result.LeagueLevel,
-		result.BadgeURL,
-		result.LeaguePosition,
-		result.HomeTeam,
-		result.MatchResult,
-		result.AwayTeam,
-		result.Points,
+// matchResultsLayout measures the match results table
+func matchResultsLayout(results []parse.MatchResult, config ImageConfig) (tableLayout, error) {
+	rows := make([][]string, 0, len(results))
+	for _, result := range results {
+		rows = append(rows, []string{
+			result.LeagueLevel,
+			result.BadgeURL,
+			result.LeaguePosition,
+			result.HomeTeam,
+			result.MatchResult,
+			result.AwayTeam,
+			result.Points,
+		})
 	}
+	return measureTable(matchResultColumns(config), rows, config)
+}
 
-	for colIndex, field := range fields {
-		dc.SetRGB(config.R, config.G, config.B)
-		x := config.Margin/2 + config.ColWidth*float64(colIndex)
-		y := config.Margin + config.RowHeight*float64(rowIndex)
-
-		if colIndex == 0 {
-			// Place the text in the center of the column
-			x += config.ColWidth / 2
-		}
-		if colIndex == 4 {
-			setDrawingColor(dc, result.MatchState)
-		}
-
-		if isImageURL(field) {
-			drawBadge(dc, field, x, y, config)
-		} else {
-			dc.DrawStringAnchored(field, x, y, 0.5, 0.5)
-		}
+// writeMatchResult writes a single match result to the image, rowIndex 0 is the header row
+func writeMatchResult(dc *gg.Context, layout tableLayout, result parse.MatchResult, badgeImages map[string]image.Image, rowIndex int) {
+	theme := layout.config.theme()
+	top := layout.rowTop(rowIndex)
+	drawRowBackground(dc, theme, rowIndex, top, layout.config)
+	if theme.LeagueAccent {
+		drawLeagueAccent(dc, result.LeagueInfo, top, layout.config)
 	}
+
+	layout.drawRow(dc, rowIndex, badgeImages, func(colIndex int) (Color, bool) {
+		return theme.stateColor(result.MatchState), colIndex == resultColumn
+	})
 }
 
 // isImageURL checks if the field is a URL pointing to a PNG image
@@ -84,119 +121,112 @@ func isImageURL(field string) bool {
 	return strings.HasPrefix(field, "http") && strings.HasSuffix(field, ".png")
 }
 
-// drawBadge downloads and draws the badge image
-func drawBadge(dc *gg.Context, url string, x, y float64, config ImageConfig) {
-	badgeImg, err := downloadImage(url)
-	if err != nil {
-		return
-	}
-	x += config.ColWidth * 1 / 4
-	y += -13
-	badgeImg = resizeImage(badgeImg, int(config.BadeWidth), int(config.BadgeHeight))
-	dc.DrawImage(badgeImg, int(x), int(y))
-}
-
-// setDrawingColor sets the drawing color based on the match state
-func setDrawingColor(dc *gg.Context, matchState string) {
-	switch matchState {
-	case "WIN":
-		dc.SetRGB(0, 1, 0)
-	case "LOSS":
-		dc.SetRGB(1, 0, 0)
-	case "DRAW":
-		dc.SetRGB(1, 1, 0)
-	default:
-		dc.SetRGB(1, 1, 1)
+// badgeCache returns the badge cache of the config or the shared default cache
+func badgeCache(config ImageConfig) *badges.Cache {
+	if config.Badges != nil {
+		return config.Badges
 	}
+	return defaultBadgeCache
 }
 
-func calculateImageSize(results []parse.MatchResult, config ImageConfig) (float64, float64) {
-	numRows := len(results)
-	if numRows == 0 {
-		return 0, 0
+// drawBadge draws the badge image with its upper left corner at x and y or a placeholder if the badge could not be loaded
+func drawBadge(dc *gg.Context, badgeImg image.Image, x, y float64, config ImageConfig) {
+	if badgeImg == nil {
+		drawPlaceholderBadge(dc, x, y, config)
+		return
 	}
-	width := calculateImageWidth(results, config)
-	height := config.RowHeight*float64(numRows) + config.Margin
-	return width, height
+	badgeImg = resizeImage(badgeImg, int(config.BadeWidth), int(config.BadgeHeight))
+	dc.DrawImage(badgeImg, int(x), int(y))
 }
 
-// calculateImageWidth calculates the width of each column based on the text width of the longest string in each column
-func calculateImageWidth(results []parse.MatchResult, config ImageConfig) float64 {
-	numColumns := 7 // Number of fields in MatchResult excluding MatchState
-	colWidths := make([]float64, numColumns)
-	for _, result := range results {
-		// Calculate the width of each column based on the text width of the longest string in each column
-		colWidths = calculateColumnWidth(result, config)
-	}
-
-	// Calculate the total width of the table
-	var totalWidth float64
-	for _, width := range colWidths {
-		totalWidth += width
-	}
-
-	return totalWidth
+// drawPlaceholderBadge draws a grey shield outline where a badge could not be loaded
+func drawPlaceholderBadge(dc *gg.Context, x, y float64, config ImageConfig) {
+	dc.Push()
+	defer dc.Pop()
+	w, h := config.BadeWidth, config.BadgeHeight
+	dc.MoveTo(x+1, y+1)
+	dc.LineTo(x+w-1, y+1)
+	dc.LineTo(x+w-1, y+h*0.55)
+	dc.QuadraticTo(x+w-1, y+h*0.85, x+w/2, y+h-1)
+	dc.QuadraticTo(x+1, y+h*0.85, x+1, y+h*0.55)
+	dc.ClosePath()
+	config.theme().Header.set(dc)
+	dc.SetLineWidth(1.5)
+	dc.Stroke()
 }
 
-func calculateColumnWidth(result parse.MatchResult, config ImageConfig) []float64 {
-	fields := []string{
-		result.LeagueLevel,
-		result.LeaguePosition,
-		result.HomeTeam,
-		result.MatchResult,
-		result.AwayTeam,
-		result.Points,
-	}
-
-	colWidths := make([]float64, len(fields))
-	for i, field := range fields {
-		colWidths[i] = calculateTextWidth(field, config) + config.Margin
+// drawRowBackground draws the zebra stripe behind every second row, top is the upper edge of the row
+func drawRowBackground(dc *gg.Context, theme Theme, rowIndex int, top float64, config ImageConfig) {
+	if rowIndex%2 == 0 || theme.Zebra.A == 0 {
+		return
 	}
-	return colWidths
+	theme.Zebra.set(dc)
+	dc.DrawRectangle(0, top, float64(dc.Width()), config.RowHeight)
+	dc.Fill()
 }
 
-func calculateTextWidth(text string, config ImageConfig) float64 {
-	dc := gg.NewContext(1, 1)
-	err := dc.LoadFontFace(os.Getenv("FONT_PATH"), config.FontSize)
-	if err != nil {
-		return 0
-	}
-	width, _ := dc.MeasureString(text)
-	return width * 2
+// drawLeagueAccent draws a bar in the colour of the league at the left edge of a row, top is the upper edge of the row
+func drawLeagueAccent(dc *gg.Context, league parse.League, top float64, config ImageConfig) {
+	leagueColor(league.R, league.G, league.B).set(dc)
+	dc.DrawRectangle(0, top+2, config.Margin/6, config.RowHeight-4)
+	dc.Fill()
 }
 
-// createNewContext creates a new drawing context with a dark background
-func createNewContext(width, height int) *gg.Context {
+// createNewContext creates a new drawing context filled with the background of the theme
+func createNewContext(width, height int, theme Theme) *gg.Context {
 	dc := gg.NewContextForRGBA(image.NewRGBA(image.Rect(0, 0, width, height)))
-	dc.SetRGB(0.1, 0.1, 0.1)
+	theme.Background.set(dc)
 	dc.Clear()
 	return dc
 }
 
-// loadFontFace loads the font face for the drawing context
-func loadFontFace(dc *gg.Context, fontSize float64) error {
-	fontPath := os.Getenv("FONT_PATH")
-	return dc.LoadFontFace(fontPath, fontSize)
+// loadFontFace sets a face of the configured font chain for the drawing context.
+// A font of the theme is put in front of the chain.
+func loadFontFace(dc *gg.Context, config ImageConfig) error {
+	chain, err := fontChain(config)
+	if err != nil {
+		return err
+	}
+	dc.SetFontFace(chain.Face(config.FontSize))
+	return nil
 }
 
-// encodeToPNG encodes the image to a PNG format
-func encodeToPNG(dc *gg.Context, buf *bytes.Buffer) error {
-	return png.Encode(buf, dc.Image())
+// themeFontKey identifies a font chain with the font of a theme in front
+type themeFontKey struct {
+	chain *fonts.Chain
+	path  string
 }
 
-// downloadImage downloads an image from a URL
-func downloadImage(url string) (image.Image, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
+// themeFontChains caches the font chains of themes so their fonts are parsed only once
+var themeFontChains sync.Map
+
+// fontChain returns the font chain of the config, the embedded default font if it has none,
+// with the font of the theme in front
+func fontChain(config ImageConfig) (*fonts.Chain, error) {
+	chain := config.Fonts
+	if chain == nil {
+		chain = defaultFontChain
+	}
+	fontPath := config.theme().FontPath
+	if fontPath == "" {
+		return chain, nil
 	}
-	defer resp.Body.Close()
 
-	img, _, err := image.Decode(resp.Body)
+	key := themeFontKey{chain: chain, path: fontPath}
+	if cached, ok := themeFontChains.Load(key); ok {
+		return cached.(*fonts.Chain), nil
+	}
+	themeChain, err := chain.WithPrimary(fontPath)
 	if err != nil {
 		return nil, err
 	}
-	return img, nil
+	themeFontChains.Store(key, themeChain)
+	return themeChain, nil
+}
+
+// encodeToPNG encodes the image to a PNG format
+func encodeToPNG(dc *gg.Context, buf *bytes.Buffer) error {
+	return png.Encode(buf, dc.Image())
 }
 
 // resizeImage resizes the image to the specified width and height
@@ -0,0 +1,113 @@
+package formatutils
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"html"
+	"math"
+	"strconv"
+)
+
+// svgFontFamily is the font of SVG text. Column widths are measured with the configured fonts,
+// so a similar monospace font keeps the text inside its cells.
+const svgFontFamily = "'Go Mono', 'Cascadia Mono', 'DejaVu Sans Mono', monospace"
+
+// MatchResultsToSVG converts match results to a scalable SVG image with the same layout as MatchResultsToImage.
+// Badges are linked by their URL instead of being downloaded.
+func MatchResultsToSVG(results []parse.MatchResult, config ImageConfig) (bytes.Buffer, error) {
+	layout, err := matchResultsLayout(results, config)
+	if err != nil {
+		return bytes.Buffer{}, err
+	}
+
+	buf := new(bytes.Buffer)
+	writeMatchResultsSVG(buf, layout, results)
+	return *buf, nil
+}
+
+// writeMatchResultsSVG writes the SVG element of a measured match results table
+func writeMatchResultsSVG(buf *bytes.Buffer, layout tableLayout, results []parse.MatchResult) {
+	config := layout.config
+	theme := config.theme()
+
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s" font-family="%s" font-size="%s" font-weight="bold">`+"\n",
+		svgNumber(layout.width), svgNumber(layout.height), svgNumber(layout.width), svgNumber(layout.height),
+		html.EscapeString(svgFontFamily), svgNumber(config.FontSize))
+	writeSVGRect(buf, 0, 0, layout.width, layout.height, theme.Background)
+
+	for colIndex, col := range layout.columns {
+		if !col.badge {
+			writeSVGText(buf, layout, col.title, colIndex, 0, theme.Header)
+		}
+	}
+
+	for i, result := range results {
+		rowIndex := i + 1
+		top := layout.rowTop(rowIndex)
+		if rowIndex%2 == 1 && theme.Zebra.A != 0 {
+			writeSVGRect(buf, 0, top, layout.width, config.RowHeight, theme.Zebra)
+		}
+		if theme.LeagueAccent {
+			league := result.LeagueInfo
+			writeSVGRect(buf, 0, top+2, config.Margin/6, config.RowHeight-4, leagueColor(league.R, league.G, league.B))
+		}
+
+		for colIndex, cell := range layout.rows[i] {
+			switch {
+			case layout.columns[colIndex].badge:
+				writeSVGBadge(buf, layout, cell, colIndex, rowIndex)
+			case colIndex == resultColumn:
+				writeSVGText(buf, layout, cell, colIndex, rowIndex, theme.stateColor(result.MatchState))
+			default:
+				writeSVGText(buf, layout, cell, colIndex, rowIndex, theme.Text)
+			}
+		}
+	}
+	buf.WriteString("</svg>\n")
+}
+
+// writeSVGRect writes a filled rectangle
+func writeSVGRect(buf *bytes.Buffer, x, y, width, height float64, color Color) {
+	fmt.Fprintf(buf, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`+"\n",
+		svgNumber(x), svgNumber(y), svgNumber(width), svgNumber(height), color.css())
+}
+
+// writeSVGText writes the text of a cell with the alignment of its column
+func writeSVGText(buf *bytes.Buffer, layout tableLayout, text string, colIndex int, rowIndex int, color Color) {
+	x, y, align := layout.textAnchor(colIndex, rowIndex)
+	anchor := "middle"
+	switch align {
+	case alignLeft:
+		anchor = "start"
+	case alignRight:
+		anchor = "end"
+	}
+	fmt.Fprintf(buf, `<text x="%s" y="%s" text-anchor="%s" dominant-baseline="central" fill="%s">%s</text>`+"\n",
+		svgNumber(x), svgNumber(y), anchor, color.css(), html.EscapeString(text))
+}
+
+// writeSVGBadge links the badge image of a cell or writes a placeholder shield if there is no badge
+func writeSVGBadge(buf *bytes.Buffer, layout tableLayout, badgeURL string, colIndex int, rowIndex int) {
+	config := layout.config
+	x, y := layout.badgeOrigin(colIndex, rowIndex)
+	w, h := config.BadeWidth, config.BadgeHeight
+	if isImageURL(badgeURL) {
+		fmt.Fprintf(buf, `<image x="%s" y="%s" width="%s" height="%s" href="%s"/>`+"\n",
+			svgNumber(x), svgNumber(y), svgNumber(w), svgNumber(h), html.EscapeString(badgeURL))
+		return
+	}
+	// Same shield as drawPlaceholderBadge
+	fmt.Fprintf(buf, `<path d="M%s %s L%s %s L%s %s Q%s %s %s %s Q%s %s %s %s Z" fill="none" stroke="%s" stroke-width="1.5"/>`+"\n",
+		svgNumber(x+1), svgNumber(y+1),
+		svgNumber(x+w-1), svgNumber(y+1),
+		svgNumber(x+w-1), svgNumber(y+h*0.55),
+		svgNumber(x+w-1), svgNumber(y+h*0.85), svgNumber(x+w/2), svgNumber(y+h-1),
+		svgNumber(x+1), svgNumber(y+h*0.85), svgNumber(x+1), svgNumber(y+h*0.55),
+		config.theme().Header.css())
+}
+
+// svgNumber formats a coordinate with at most two decimals
+func svgNumber(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,73 @@
+package formatutils_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/formatutils"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"io"
+	"strings"
+	"testing"
+)
+
+var svgTestResults = []parse.MatchResult{
+	{LeagueLevel: "OL1", BadgeURL: "https://example.com/badge.png", LeaguePosition: "#1", HomeTeam: "Test A", MatchResult: "4 : 3", MatchState: "WIN", AwayTeam: "Test & B", Points: "3 pts"},
+	{LeagueLevel: "OL2", LeaguePosition: "#12", HomeTeam: "<Test C>", MatchResult: "0 : 1", MatchState: "LOSS", AwayTeam: "Test D", Points: "0 pts"},
+}
+
+var svgTestConfig = formatutils.ImageConfig{FontSize: 14, Margin: 28, RowHeight: 28, BadeWidth: 22, BadgeHeight: 22}
+
+func TestMatchResultsToSVG(t *testing.T) {
+	buf, err := formatutils.MatchResultsToSVG(svgTestResults, svgTestConfig)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var texts []string
+	images := 0
+	decoder := xml.NewDecoder(bytes.NewReader(buf.Bytes()))
+	inText := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Expected well-formed SVG, got %v", err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			inText = token.Name.Local == "text"
+			if token.Name.Local == "image" {
+				images++
+			}
+		case xml.CharData:
+			if inText {
+				texts = append(texts, string(token))
+			}
+		case xml.EndElement:
+			inText = false
+		}
+	}
+
+	expected := "OL,#,Home,Result,Away,Pts,OL1,#1,Test A,4 : 3,Test & B,3 pts,OL2,#12,<Test C>,0 : 1,Test D,0 pts"
+	if got := strings.Join(texts, ","); got != expected {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if images != 1 {
+		t.Errorf("Expected %v, got %v", 1, images)
+	}
+}
+
+func TestMatchResultsToHTML(t *testing.T) {
+	buf, err := formatutils.MatchResultsToHTML(svgTestResults, "Results <.de>", svgTestConfig)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	page := buf.String()
+	for _, expected := range []string{"<!DOCTYPE html>", "<title>Results &lt;.de&gt;</title>", "<svg ", "&lt;Test C&gt;", "</html>"} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Expected the page to contain %v, got %v", expected, page)
+		}
+	}
+}
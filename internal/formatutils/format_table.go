@@ -0,0 +1,126 @@
+package formatutils
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// TableConfig configures the league table image
+type TableConfig struct {
+	ImageConfig
+	// PromotionSpots is the number of teams at the top of the table that are promoted (ignored for the first league)
+	PromotionSpots int
+	// RelegationSpots is the number of teams at the bottom of the table that are relegated
+	RelegationSpots int
+}
+
+// leagueTableColumns returns the columns of the league table image
+func leagueTableColumns(config ImageConfig) []column {
+	return []column{
+		{title: "#", align: alignRight},
+		{title: "Team", align: alignLeft, maxWidth: config.maxTeamWidth()},
+		{title: "MP", align: alignRight},
+		{title: "W", align: alignRight},
+		{title: "D", align: alignRight},
+		{title: "L", align: alignRight},
+		{title: "Goals", align: alignCenter},
+		{title: "+/-", align: alignRight},
+		{title: "Pts", align: alignRight},
+	}
+}
+
+// LeagueTableToImage renders a complete league table. Promotion and relegation zones are coloured
+// and the rows of highlighted users are drawn in an accent colour.
+func LeagueTableToImage(table []parse.LeagueTable, league parse.League, highlightedUserIDs []int, config TableConfig) (bytes.Buffer, error) {
+	if len(table) == 0 {
+		return bytes.Buffer{}, &FormatError{Msg: "Error: The league table is empty"}
+	}
+
+	// Sort a copy of the table by rank so the caller's slice stays untouched
+	rows := slices.Clone(table)
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Rank < rows[j].Rank
+	})
+
+	layout, err := leagueTableLayout(rows, config.ImageConfig)
+	if err != nil {
+		return bytes.Buffer{}, err
+	}
+	dc, err := layout.newContext()
+	if err != nil {
+		return bytes.Buffer{}, err
+	}
+
+	theme := config.theme()
+	tableWidth := layout.tableWidth()
+	if theme.LeagueAccent {
+		// Underline the header in the colour of the league
+		leagueColor(league.R, league.G, league.B).set(dc)
+		dc.DrawRectangle(config.Margin/2, layout.rowTop(1)-2, tableWidth, 2)
+		dc.Fill()
+	}
+	layout.drawHeader(dc)
+
+	promotionSpots := config.PromotionSpots
+	if league.Level == 1 {
+		promotionSpots = 0
+	}
+
+	for i, row := range rows {
+		rowIndex := i + 1
+		top := layout.rowTop(rowIndex)
+
+		// Colour the background of the promotion and relegation zones
+		drawRowBackground(dc, theme, rowIndex, top, config.ImageConfig)
+		switch {
+		case row.Rank <= promotionSpots:
+			theme.Promotion.set(dc)
+			dc.DrawRectangle(config.Margin/2, top, tableWidth, config.RowHeight)
+			dc.Fill()
+		case row.Rank > len(rows)-config.RelegationSpots:
+			theme.Relegation.set(dc)
+			dc.DrawRectangle(config.Margin/2, top, tableWidth, config.RowHeight)
+			dc.Fill()
+		}
+
+		highlighted := slices.Contains(highlightedUserIDs, row.UserID)
+		layout.drawRow(dc, rowIndex, nil, func(int) (Color, bool) {
+			return theme.Highlight, highlighted
+		})
+	}
+
+	buf := new(bytes.Buffer)
+	if err := encodeToPNG(dc, buf); err != nil {
+		return *buf, err
+	}
+	return *buf, nil
+}
+
+// leagueTableLayout measures the league table, rows have to be sorted by rank
+func leagueTableLayout(rows []parse.LeagueTable, config ImageConfig) (tableLayout, error) {
+	cells := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		cells = append(cells, leagueTableRow(row))
+	}
+	return measureTable(leagueTableColumns(config), cells, config)
+}
+
+// leagueTableRow returns the cells of a single league table row
+func leagueTableRow(row parse.LeagueTable) []string {
+	goalDifference := row.ScoredGoals - row.ConcedingGoals
+	return []string{
+		strconv.Itoa(row.Rank),
+		row.TeamName,
+		strconv.Itoa(row.MatchCount),
+		strconv.Itoa(row.Win),
+		strconv.Itoa(row.Draw),
+		strconv.Itoa(row.Lost),
+		fmt.Sprintf("%d:%d", row.ScoredGoals, row.ConcedingGoals),
+		fmt.Sprintf("%+d", goalDifference),
+		strconv.Itoa(row.Points),
+	}
+}
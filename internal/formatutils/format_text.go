@@ -0,0 +1,188 @@
+package formatutils
+
+import (
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"golang.org/x/text/width"
+	"strings"
+	"unicode"
+)
+
+// MaxMessageLength is the maximum number of characters discord accepts in a message
+const MaxMessageLength = 2000
+
+// maxTeamNameWidth is the number of cells after which team names are cut in text tables
+const maxTeamNameWidth = 20
+
+// ANSI escape sequences supported by discord's ansi code blocks
+const (
+	ansiReset  = "\x1b[0m"
+	ansiGray   = "\x1b[30m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+)
+
+// alignment is the horizontal alignment of a text table column
+type alignment int
+
+const (
+	alignLeft alignment = iota
+	alignCenter
+	alignRight
+)
+
+// textColumns describes the columns of the text table in order: title and alignment
+var textColumns = []struct {
+	title string
+	align alignment
+}{
+	{"OL", alignLeft},
+	{"#", alignRight},
+	{"Home", alignRight},
+	{"Result", alignCenter},
+	{"Away", alignLeft},
+	{"Pts", alignRight},
+}
+
+// MatchResultsToText converts match results to an aligned table inside ```ansi code blocks with the
+// result coloured by match state like the image themes do. The table is split into
+// several code blocks that each fit into maxLength characters, every block repeats the header.
+func MatchResultsToText(results []parse.MatchResult, maxLength int) []string {
+	rows := make([][]string, 0, len(results))
+	for _, result := range results {
+		rows = append(rows, []string{
+			result.LeagueLevel,
+			result.LeaguePosition,
+			truncateToWidth(result.HomeTeam, maxTeamNameWidth),
+			result.MatchResult,
+			truncateToWidth(result.AwayTeam, maxTeamNameWidth),
+			result.Points,
+		})
+	}
+
+	// Every column is as wide as its widest cell
+	colWidths := make([]int, len(textColumns))
+	for colIndex, column := range textColumns {
+		colWidths[colIndex] = displayWidth(column.title)
+	}
+	for _, row := range rows {
+		for colIndex, cell := range row {
+			colWidths[colIndex] = max(colWidths[colIndex], displayWidth(cell))
+		}
+	}
+
+	headerCells := make([]string, len(textColumns))
+	for colIndex, column := range textColumns {
+		headerCells[colIndex] = column.title
+	}
+	header := ansiGray + formatTextRow(headerCells, colWidths, -1, "") + ansiReset + "\n"
+
+	const blockStart, blockEnd = "```ansi\n", "```"
+	var blocks []string
+	var block strings.Builder
+	blockLength := 0
+	for rowIndex, row := range rows {
+		line := formatTextRow(row, colWidths, 3, stateANSIColor(results[rowIndex].MatchState)) + "\n"
+		lineLength := len([]rune(line))
+		if block.Len() > 0 && blockLength+lineLength+len(blockEnd) > maxLength {
+			block.WriteString(blockEnd)
+			blocks = append(blocks, block.String())
+			block.Reset()
+		}
+		if block.Len() == 0 {
+			block.WriteString(blockStart + header)
+			blockLength = len([]rune(blockStart + header))
+		}
+		block.WriteString(line)
+		blockLength += lineLength
+	}
+	if block.Len() > 0 {
+		block.WriteString(blockEnd)
+		blocks = append(blocks, block.String())
+	}
+	return blocks
+}
+
+// formatTextRow pads every cell to the width of its column and colours the cell at colorIndex
+func formatTextRow(cells []string, colWidths []int, colorIndex int, color string) string {
+	padded := make([]string, len(cells))
+	for colIndex, cell := range cells {
+		padded[colIndex] = pad(cell, colWidths[colIndex], textColumns[colIndex].align)
+		if colIndex == colorIndex && color != "" {
+			padded[colIndex] = color + padded[colIndex] + ansiReset
+		}
+	}
+	return strings.TrimRight(strings.Join(padded, " "), " ")
+}
+
+// pad fills text with spaces up to the given display width
+func pad(text string, cells int, align alignment) string {
+	missing := max(cells-displayWidth(text), 0)
+	switch align {
+	case alignRight:
+		return strings.Repeat(" ", missing) + text
+	case alignCenter:
+		return strings.Repeat(" ", missing/2) + text + strings.Repeat(" ", missing-missing/2)
+	default:
+		return text + strings.Repeat(" ", missing)
+	}
+}
+
+// stateANSIColor returns the ANSI colour of a match state, matching the colours of the dark theme
+func stateANSIColor(matchState string) string {
+	switch matchState {
+	case "WIN":
+		return ansiGreen
+	case "LOSS":
+		return ansiRed
+	case "DRAW":
+		return ansiYellow
+	default:
+		return ""
+	}
+}
+
+// displayWidth returns the number of monospace cells text occupies. East asian wide characters
+// take two cells, combining marks and zero width characters none.
+func displayWidth(text string) int {
+	cells := 0
+	for _, r := range text {
+		cells += runeWidth(r)
+	}
+	return cells
+}
+
+// runeWidth returns the number of monospace cells of a single rune
+func runeWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r >= 0x1F300 && r <= 0x1FAFF:
+		// Emoji are rendered with two cells although unicode lists most of them as neutral
+		return 2
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// truncateToWidth cuts text to at most cells display cells and marks the cut with an ellipsis
+func truncateToWidth(text string, cells int) string {
+	if displayWidth(text) <= cells {
+		return text
+	}
+	var sb strings.Builder
+	used := 0
+	for _, r := range text {
+		runeCells := runeWidth(r)
+		if used+runeCells > cells-1 {
+			break
+		}
+		sb.WriteRune(r)
+		used += runeCells
+	}
+	return sb.String() + "…"
+}
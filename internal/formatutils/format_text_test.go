@@ -0,0 +1,41 @@
+package formatutils_test
+
+import (
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/formatutils"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"strings"
+	"testing"
+)
+
+func TestMatchResultsToText(t *testing.T) {
+	results := []parse.MatchResult{
+		{LeagueLevel: "OL1", LeaguePosition: "#1", HomeTeam: "Test A", MatchResult: "4 : 3", MatchState: "WIN", AwayTeam: "Test B", Points: "3 pts"},
+		{LeagueLevel: "OL2", LeaguePosition: "#12", HomeTeam: "東京ユナイテッド", MatchResult: "0 : 1", MatchState: "LOSS", AwayTeam: "A very very long team name", Points: "0 pts"},
+	}
+
+	blocks := formatutils.MatchResultsToText(results, formatutils.MaxMessageLength)
+	if len(blocks) != 1 {
+		t.Fatalf("Expected 1 block, got %d", len(blocks))
+	}
+	expectedLines := []string{
+		"```ansi",
+		"\x1b[30mOL    #             Home Result Away                   Pts\x1b[0m",
+		"OL1  #1           Test A \x1b[32m4 : 3 \x1b[0m Test B               3 pts",
+		"OL2 #12 東京ユナイテッド \x1b[31m0 : 1 \x1b[0m A very very long te… 0 pts",
+		"```",
+	}
+	if blocks[0] != strings.Join(expectedLines, "\n") {
+		t.Errorf("Expected\n%q\ngot\n%q", strings.Join(expectedLines, "\n"), blocks[0])
+	}
+
+	// A small limit splits the table, every block repeats the header and stays within the limit
+	blocks = formatutils.MatchResultsToText(results, 150)
+	if len(blocks) != 2 {
+		t.Fatalf("Expected 2 blocks, got %d", len(blocks))
+	}
+	for _, block := range blocks {
+		if len([]rune(block)) > 150 || !strings.HasPrefix(block, "```ansi\n\x1b[30mOL") || !strings.HasSuffix(block, "```") {
+			t.Errorf("Unexpected block %q", block)
+		}
+	}
+}
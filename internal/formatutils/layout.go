@@ -0,0 +1,201 @@
+package formatutils
+
+import (
+	"github.com/fogleman/gg"
+	"image"
+)
+
+// defaultMaxTeamWidth is the width in pixels after which team names are ellipsised if the config sets no limit
+const defaultMaxTeamWidth = 220.0
+
+// column describes a column of a table image
+type column struct {
+	title string
+	align alignment
+	// maxWidth limits the text width in pixels, longer text is ellipsised. Zero means unlimited.
+	maxWidth float64
+	// badge columns hold badge URLs and are exactly as wide as a badge
+	badge bool
+}
+
+// tableLayout is the measured layout of a table image with a header row followed by the data rows.
+// Every column is as wide as its widest cell plus the margin as padding.
+type tableLayout struct {
+	columns []column
+	// rows hold the cells of the data rows after ellipsising
+	rows   [][]string
+	widths []float64
+	width  float64
+	height float64
+	config ImageConfig
+}
+
+// measureTable measures all cells with the font of the config and returns the resulting layout
+func measureTable(columns []column, rows [][]string, config ImageConfig) (tableLayout, error) {
+	measureCtx := gg.NewContext(1, 1)
+	if err := loadFontFace(measureCtx, config); err != nil {
+		return tableLayout{}, err
+	}
+
+	layout := tableLayout{
+		columns: columns,
+		rows:    make([][]string, len(rows)),
+		widths:  make([]float64, len(columns)),
+		config:  config,
+	}
+	for colIndex, col := range columns {
+		if col.badge {
+			layout.widths[colIndex] = config.BadeWidth
+			continue
+		}
+		layout.widths[colIndex], _ = measureCtx.MeasureString(col.title)
+	}
+	for rowIndex, row := range rows {
+		layout.rows[rowIndex] = make([]string, len(row))
+		for colIndex, cell := range row {
+			col := columns[colIndex]
+			if col.badge {
+				layout.rows[rowIndex][colIndex] = cell
+				continue
+			}
+			if col.maxWidth > 0 {
+				cell = ellipsise(measureCtx, cell, col.maxWidth)
+			}
+			layout.rows[rowIndex][colIndex] = cell
+			cellWidth, _ := measureCtx.MeasureString(cell)
+			layout.widths[colIndex] = max(layout.widths[colIndex], cellWidth)
+		}
+	}
+
+	for colIndex := range layout.widths {
+		layout.widths[colIndex] += config.Margin
+		layout.width += layout.widths[colIndex]
+	}
+	layout.width += config.Margin
+	layout.height = config.RowHeight*float64(len(rows)+1) + config.Margin
+	return layout, nil
+}
+
+// ellipsise shortens text until it fits into maxWidth including a trailing ellipsis
+func ellipsise(measureCtx *gg.Context, text string, maxWidth float64) string {
+	if width, _ := measureCtx.MeasureString(text); width <= maxWidth {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := string(runes) + "…"
+		if width, _ := measureCtx.MeasureString(candidate); width <= maxWidth {
+			return candidate
+		}
+	}
+	return "…"
+}
+
+// newContext creates a drawing context of the size of the layout with the font of the config
+func (l tableLayout) newContext() (*gg.Context, error) {
+	dc := createNewContext(int(l.width), int(l.height), l.config.theme())
+	if err := loadFontFace(dc, l.config); err != nil {
+		return nil, err
+	}
+	return dc, nil
+}
+
+// rowTop returns the upper edge of a row. Row 0 is the header, data rows start at 1.
+func (l tableLayout) rowTop(rowIndex int) float64 {
+	return l.config.Margin/2 + l.config.RowHeight*float64(rowIndex)
+}
+
+// colLeft returns the left edge of a column
+func (l tableLayout) colLeft(colIndex int) float64 {
+	x := l.config.Margin / 2
+	for _, width := range l.widths[:colIndex] {
+		x += width
+	}
+	return x
+}
+
+// tableWidth returns the width of all columns without the outer margin
+func (l tableLayout) tableWidth() float64 {
+	return l.width - l.config.Margin
+}
+
+// drawHeader draws the column titles in the header colour of the theme
+func (l tableLayout) drawHeader(dc *gg.Context) {
+	l.config.theme().Header.set(dc)
+	for colIndex, col := range l.columns {
+		if !col.badge {
+			l.drawText(dc, col.title, colIndex, 0)
+		}
+	}
+}
+
+// drawText draws text into a cell with the alignment of its column using the current colour
+func (l tableLayout) drawText(dc *gg.Context, text string, colIndex int, rowIndex int) {
+	x, y, align := l.textAnchor(colIndex, rowIndex)
+	switch align {
+	case alignLeft:
+		dc.DrawStringAnchored(text, x, y, 0, 0.5)
+	case alignRight:
+		dc.DrawStringAnchored(text, x, y, 1, 0.5)
+	default:
+		dc.DrawStringAnchored(text, x, y, 0.5, 0.5)
+	}
+}
+
+// textAnchor returns the point text in a cell is aligned to, vertically it is the middle of the row
+func (l tableLayout) textAnchor(colIndex int, rowIndex int) (float64, float64, alignment) {
+	padding := l.config.Margin / 2
+	left := l.colLeft(colIndex)
+	y := l.rowTop(rowIndex) + l.config.RowHeight/2
+	align := l.columns[colIndex].align
+	switch align {
+	case alignLeft:
+		return left + padding, y, align
+	case alignRight:
+		return left + l.widths[colIndex] - padding, y, align
+	default:
+		return left + l.widths[colIndex]/2, y, align
+	}
+}
+
+// badgeOrigin returns the upper left corner of a badge centered in a cell
+func (l tableLayout) badgeOrigin(colIndex int, rowIndex int) (float64, float64) {
+	x := l.colLeft(colIndex) + (l.widths[colIndex]-l.config.BadeWidth)/2
+	y := l.rowTop(rowIndex) + (l.config.RowHeight-l.config.BadgeHeight)/2
+	return x, y
+}
+
+// drawBadgeCell draws a badge centered into a cell, or a placeholder if it could not be loaded
+func (l tableLayout) drawBadgeCell(dc *gg.Context, badgeImg image.Image, colIndex int, rowIndex int) {
+	x, y := l.badgeOrigin(colIndex, rowIndex)
+	drawBadge(dc, badgeImg, x, y, l.config)
+}
+
+// drawRow draws the cells of a data row. Text cells use the text colour of the theme unless
+// cellColor returns another colour for them, badge cells are looked up in badgeImages.
+func (l tableLayout) drawRow(dc *gg.Context, rowIndex int, badgeImages map[string]image.Image, cellColor func(colIndex int) (Color, bool)) {
+	theme := l.config.theme()
+	for colIndex, cell := range l.rows[rowIndex-1] {
+		if l.columns[colIndex].badge {
+			l.drawBadgeCell(dc, badgeImages[cell], colIndex, rowIndex)
+			continue
+		}
+		color := theme.Text
+		if cellColor != nil {
+			if override, ok := cellColor(colIndex); ok {
+				color = override
+			}
+		}
+		color.set(dc)
+		l.drawText(dc, cell, colIndex, rowIndex)
+	}
+}
+
+// maxTeamWidth returns the configured maximum width of team names
+func (config ImageConfig) maxTeamWidth() float64 {
+	if config.MaxTeamWidth > 0 {
+		return config.MaxTeamWidth
+	}
+	return defaultMaxTeamWidth
+}
@@ -0,0 +1,94 @@
+package formatutils_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/formatutils"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"image/png"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMatchResultsToImageEllipsisesLongTeamNames(t *testing.T) {
+	config := formatutils.ImageConfig{FontSize: 14, Margin: 28, RowHeight: 28, MaxTeamWidth: 120, BadeWidth: 22, BadgeHeight: 22}
+	imageWidth := func(awayTeam string) int {
+		results := []parse.MatchResult{
+			{LeagueLevel: "OL1", LeaguePosition: "#1", HomeTeam: "Test A", MatchResult: "4 : 3", MatchState: "WIN", AwayTeam: awayTeam, Points: "3 pts"},
+		}
+		buf, err := formatutils.MatchResultsToImage(context.Background(), results, config)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("Expected a PNG image, got %v", err)
+		}
+		return img.Bounds().Dx()
+	}
+
+	short := imageWidth("Test B")
+	long := imageWidth(strings.Repeat("Very long team name ", 5))
+	longer := imageWidth(strings.Repeat("Very long team name ", 10))
+	if long <= short {
+		t.Errorf("Expected a long team name to widen the image beyond %d, got %d", short, long)
+	}
+	if long != longer {
+		t.Errorf("Expected the width of ellipsised team names to be capped at %d, got %d", long, longer)
+	}
+}
+
+func TestLeagueTableToImageZones(t *testing.T) {
+	config := formatutils.TableConfig{
+		ImageConfig:     formatutils.ImageConfig{FontSize: 14, Margin: 28, RowHeight: 28, MaxTeamWidth: 120, Theme: formatutils.Themes["dark"]},
+		PromotionSpots:  1,
+		RelegationSpots: 1,
+	}
+	// The table arrives out of order and is drawn by rank
+	table := []parse.LeagueTable{
+		{Rank: 3, UserID: 3, TeamName: "Test C"},
+		{Rank: 1, UserID: 1, TeamName: "Test A"},
+		{Rank: 4, UserID: 4, TeamName: "Test D"},
+		{Rank: 2, UserID: 2, TeamName: "Test B"},
+	}
+
+	// zones returns the zone of every row by the colour at the left edge of the row
+	zones := func(league parse.League) []string {
+		buf, err := formatutils.LeagueTableToImage(table, league, nil, config)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("Expected a PNG image, got %v", err)
+		}
+		var rowZones []string
+		for rowIndex := 1; rowIndex <= len(table); rowIndex++ {
+			top := config.Margin/2 + config.RowHeight*float64(rowIndex)
+			r, g, _, _ := img.At(int(config.Margin/2)+1, int(top)+1).RGBA()
+			switch {
+			case g > r:
+				rowZones = append(rowZones, "promotion")
+			case r > g:
+				rowZones = append(rowZones, "relegation")
+			default:
+				rowZones = append(rowZones, "none")
+			}
+		}
+		return rowZones
+	}
+
+	expected := []string{"promotion", "none", "none", "relegation"}
+	if got := zones(parse.League{Level: 2}); !slices.Equal(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	// Nobody is promoted from the first league
+	expected = []string{"none", "none", "none", "relegation"}
+	if got := zones(parse.League{Level: 1}); !slices.Equal(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if table[0].Rank != 3 {
+		t.Errorf("Expected the table of the caller to stay unsorted, got rank %d first", table[0].Rank)
+	}
+}
@@ -0,0 +1,158 @@
+package formatutils
+
+import (
+	"fmt"
+	"github.com/fogleman/gg"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// DefaultThemeName is the theme that is used if neither the command nor the guild choose one
+const DefaultThemeName = "dark"
+
+// Color is an RGBA colour with components between 0 and 1
+type Color struct {
+	R, G, B, A float64
+}
+
+// rgb returns an opaque colour
+func rgb(r, g, b float64) Color {
+	return Color{R: r, G: g, B: b, A: 1}
+}
+
+// set makes the colour the current drawing colour
+func (c Color) set(dc *gg.Context) {
+	dc.SetRGBA(c.R, c.G, c.B, c.A)
+}
+
+// css returns the colour as a CSS rgba() value, which SVG understands as well
+func (c Color) css() string {
+	return fmt.Sprintf("rgba(%d,%d,%d,%s)", colorByte(c.R), colorByte(c.G), colorByte(c.B), strconv.FormatFloat(c.A, 'f', -1, 64))
+}
+
+// colorByte converts a colour component between 0 and 1 to a value between 0 and 255
+func colorByte(component float64) int {
+	return int(math.Round(min(max(component, 0), 1) * 255))
+}
+
+// Theme defines the colours and the font of the rendered images
+type Theme struct {
+	Name       string
+	Background Color
+	Text       Color
+	Header     Color
+	// Zebra is drawn behind every second row, a transparent colour disables striping
+	Zebra      Color
+	Win        Color
+	Loss       Color
+	Draw       Color
+	Highlight  Color
+	Promotion  Color
+	Relegation Color
+	// LeagueAccent draws a bar in the colour of the league (League.R/G/B) in front of every row
+	LeagueAccent bool
+	// FontPath is a font file that is put in front of the configured font chain
+	FontPath string
+}
+
+// Themes are the built-in themes by name
+var Themes = map[string]Theme{
+	"dark": {
+		Name:       "dark",
+		Background: rgb(0.1, 0.1, 0.1),
+		Text:       rgb(1, 1, 1),
+		Header:     rgb(0.6, 0.6, 0.6),
+		Win:        rgb(0, 1, 0),
+		Loss:       rgb(1, 0, 0),
+		Draw:       rgb(1, 1, 0),
+		Highlight:  rgb(1, 0.8, 0),
+		Promotion:  Color{R: 0, G: 1, B: 0, A: 0.15},
+		Relegation: Color{R: 1, G: 0, B: 0, A: 0.15},
+	},
+	"light": {
+		Name:       "light",
+		Background: rgb(0.97, 0.97, 0.97),
+		Text:       rgb(0.1, 0.1, 0.1),
+		Header:     rgb(0.45, 0.45, 0.45),
+		Zebra:      Color{R: 0, G: 0, B: 0, A: 0.05},
+		Win:        rgb(0.1, 0.6, 0.2),
+		Loss:       rgb(0.8, 0.1, 0.1),
+		Draw:       rgb(0.75, 0.55, 0),
+		Highlight:  rgb(0.1, 0.35, 0.85),
+		Promotion:  Color{R: 0.1, G: 0.6, B: 0.2, A: 0.15},
+		Relegation: Color{R: 0.8, G: 0.1, B: 0.1, A: 0.15},
+	},
+	"contrast": {
+		Name:       "contrast",
+		Background: rgb(0, 0, 0),
+		Text:       rgb(1, 1, 1),
+		Header:     rgb(1, 1, 1),
+		Zebra:      Color{R: 1, G: 1, B: 1, A: 0.12},
+		Win:        rgb(0.3, 1, 0.3),
+		Loss:       rgb(1, 0.35, 0.35),
+		Draw:       rgb(1, 1, 0.3),
+		Highlight:  rgb(0.3, 0.85, 1),
+		Promotion:  Color{R: 0.3, G: 1, B: 0.3, A: 0.25},
+		Relegation: Color{R: 1, G: 0.35, B: 0.35, A: 0.25},
+	},
+	"league": {
+		Name:         "league",
+		Background:   rgb(0.13, 0.14, 0.17),
+		Text:         rgb(0.95, 0.95, 0.95),
+		Header:       rgb(0.6, 0.65, 0.7),
+		Zebra:        Color{R: 1, G: 1, B: 1, A: 0.04},
+		Win:          rgb(0.2, 0.85, 0.4),
+		Loss:         rgb(0.95, 0.3, 0.3),
+		Draw:         rgb(0.95, 0.8, 0.2),
+		Highlight:    rgb(1, 0.8, 0),
+		Promotion:    Color{R: 0.2, G: 0.85, B: 0.4, A: 0.15},
+		Relegation:   Color{R: 0.95, G: 0.3, B: 0.3, A: 0.15},
+		LeagueAccent: true,
+	},
+}
+
+// ThemeNames returns the names of all built-in themes in alphabetical order
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ThemeByName returns the built-in theme with the given name or the default theme if it does not exist
+func ThemeByName(name string) Theme {
+	if theme, ok := Themes[name]; ok {
+		return theme
+	}
+	return Themes[DefaultThemeName]
+}
+
+// theme returns the theme of the config or the default theme
+func (config ImageConfig) theme() Theme {
+	if config.Theme.Name == "" {
+		return Themes[DefaultThemeName]
+	}
+	return config.Theme
+}
+
+// stateColor returns the colour of a match state (WIN, LOSS, DRAW)
+func (t Theme) stateColor(matchState string) Color {
+	switch matchState {
+	case "WIN":
+		return t.Win
+	case "LOSS":
+		return t.Loss
+	case "DRAW":
+		return t.Draw
+	default:
+		return t.Text
+	}
+}
+
+// leagueColor converts the 0-255 colour components of a league to a Color
+func leagueColor(r, g, b int) Color {
+	return rgb(float64(r)/255, float64(g)/255, float64(b)/255)
+}
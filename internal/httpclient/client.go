@@ -1,16 +1,32 @@
 package httpclient
 
 import (
+	"net"
 	"net/http"
 	"time"
 )
 
-// DefaultHTTPClient is the default HTTP client used by discordgo.
+// AttemptTimeout bounds connecting and waiting for the response headers of a single attempt. It applies below
+// the retries and the rate limiter, so neither the backoff nor the queue count against it.
+const AttemptTimeout = 30 * time.Second
+
+// DefaultHostLimiter limits the request rate of DefaultHTTPClient per host, including retries.
+// Its limits may be changed at startup.
+var DefaultHostLimiter = NewHostLimiter(&http.Transport{
+	Proxy:                 http.ProxyFromEnvironment,
+	DialContext:           (&net.Dialer{Timeout: AttemptTimeout, KeepAlive: 30 * time.Second}).DialContext,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ResponseHeaderTimeout: AttemptTimeout,
+	MaxConnsPerHost:       100,
+	MaxIdleConnsPerHost:   20,
+}, DefaultHostLimit)
+
+// DefaultRetryTransport retries the requests of DefaultHTTPClient, its Config may be replaced at startup
+var DefaultRetryTransport = NewRetryTransport(DefaultHostLimiter, DefaultRetryConfig)
+
+// DefaultHTTPClient is the default HTTP client used by discordgo. It has no overall timeout, every attempt is
+// bounded by AttemptTimeout and the whole request including retries by the context of the request.
 var DefaultHTTPClient = &http.Client{
-	Jar: Cookie(),
-	Transport: &http.Transport{
-		MaxConnsPerHost:     100,
-		MaxIdleConnsPerHost: 20,
-	},
-	Timeout: 30 * time.Second,
+	Jar:       Cookie(),
+	Transport: DefaultRetryTransport,
 }
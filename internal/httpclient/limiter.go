@@ -0,0 +1,219 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UserAgent identifies the bot in all requests that do not set their own User-Agent
+const UserAgent = "ol_discord_bot (+https://github.com/tripleawwy/onlineliga_discord_bot)"
+
+// HostLimit is the request rate allowed for a host
+type HostLimit struct {
+	// Rate is the number of requests per second, 0 disables the limit
+	Rate float64
+	// Burst is the number of requests that may be sent at once after a quiet period
+	Burst int
+}
+
+// DefaultHostLimit applies to every host without a limit of its own
+var DefaultHostLimit = HostLimit{Rate: 5, Burst: 10}
+
+// ParseHostLimit parses a limit given as "rate" or "rate:burst", e.g. "5:10". Without a burst it is the rate rounded up.
+func ParseHostLimit(value string) (HostLimit, error) {
+	rateValue, burstValue, hasBurst := strings.Cut(strings.TrimSpace(value), ":")
+	rate, err := strconv.ParseFloat(rateValue, 64)
+	if err != nil || rate < 0 {
+		return HostLimit{}, fmt.Errorf("invalid rate %q", rateValue)
+	}
+	limit := HostLimit{Rate: rate, Burst: max(int(rate+0.999), 1)}
+	if hasBurst {
+		limit.Burst, err = strconv.Atoi(burstValue)
+		if err != nil || limit.Burst < 1 {
+			return HostLimit{}, fmt.Errorf("invalid burst %q", burstValue)
+		}
+	}
+	return limit, nil
+}
+
+// HostStats are the queueing metrics of a host
+type HostStats struct {
+	// Requests is the number of requests that passed the limiter
+	Requests int64
+	// Delayed is the number of requests that had to wait for a token
+	Delayed int64
+	// Waiting is the number of requests currently waiting for a token
+	Waiting int64
+	// TotalWait is the time all requests have waited for a token
+	TotalWait time.Duration
+	// MaxWait is the longest time a request has waited for a token
+	MaxWait time.Duration
+}
+
+// HostLimiter is an http.RoundTripper that limits the request rate per host with a token bucket and sets the
+// User-Agent of the bot. All users of one HostLimiter share the buckets.
+//
+// Requests wait in the queue of their host as long as it takes, the wait is only bounded by the context of the
+// request. Timeouts of the transport below, like AttemptTimeout, start once the request leaves the queue.
+type HostLimiter struct {
+	next http.RoundTripper
+
+	mu           sync.Mutex
+	userAgent    string
+	defaultLimit HostLimit
+	limits       map[string]HostLimit
+	buckets      map[string]*tokenBucket
+	stats        map[string]*HostStats
+}
+
+// NewHostLimiter wraps next, a nil next uses http.DefaultTransport
+func NewHostLimiter(next http.RoundTripper, defaultLimit HostLimit) *HostLimiter {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &HostLimiter{
+		next:         next,
+		userAgent:    UserAgent,
+		defaultLimit: defaultLimit,
+		limits:       map[string]HostLimit{},
+		buckets:      map[string]*tokenBucket{},
+		stats:        map[string]*HostStats{},
+	}
+}
+
+// SetDefaultLimit changes the limit of all hosts without a limit of their own
+func (l *HostLimiter) SetDefaultLimit(limit HostLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.defaultLimit = limit
+	l.buckets = map[string]*tokenBucket{}
+}
+
+// SetLimit changes the limit of a host like www.onlineliga.de
+func (l *HostLimiter) SetLimit(host string, limit HostLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits[host] = limit
+	delete(l.buckets, host)
+}
+
+// SetUserAgent changes the User-Agent that is set on requests without one
+func (l *HostLimiter) SetUserAgent(userAgent string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.userAgent = userAgent
+}
+
+// Stats returns the queueing metrics of all hosts that have been requested
+func (l *HostLimiter) Stats() map[string]HostStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stats := make(map[string]HostStats, len(l.stats))
+	for host, hostStats := range l.stats {
+		stats[host] = *hostStats
+	}
+	return stats
+}
+
+// RoundTrip implements http.RoundTripper. It waits for a token of the host until the context of req is done,
+// in which case the token is given back.
+func (l *HostLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	wait, userAgent := l.reserve(host)
+	if wait > 0 {
+		if err := sleep(req.Context(), wait); err != nil {
+			l.cancel(host)
+			return nil, err
+		}
+		l.done(host, wait)
+	}
+
+	if req.Header.Get("User-Agent") == "" {
+		// A RoundTripper must not modify the request of the caller
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", userAgent)
+	}
+	return l.next.RoundTrip(req)
+}
+
+// reserve takes a token of the host and returns how long the request has to wait for it
+func (l *HostLimiter) reserve(host string) (time.Duration, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	bucket, ok := l.buckets[host]
+	if !ok {
+		limit, ok := l.limits[host]
+		if !ok {
+			limit = l.defaultLimit
+		}
+		bucket = newTokenBucket(limit, time.Now())
+		l.buckets[host] = bucket
+	}
+	hostStats, ok := l.stats[host]
+	if !ok {
+		hostStats = &HostStats{}
+		l.stats[host] = hostStats
+	}
+
+	wait := bucket.reserve(time.Now())
+	if wait > 0 {
+		hostStats.Delayed++
+		hostStats.Waiting++
+	} else {
+		hostStats.Requests++
+	}
+	return wait, l.userAgent
+}
+
+// done records a request that has waited for its token
+func (l *HostLimiter) done(host string, wait time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	hostStats := l.stats[host]
+	hostStats.Waiting--
+	hostStats.Requests++
+	hostStats.TotalWait += wait
+	hostStats.MaxWait = max(hostStats.MaxWait, wait)
+}
+
+// cancel gives the token of a request back that was cancelled while waiting
+func (l *HostLimiter) cancel(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats[host].Waiting--
+	if bucket, ok := l.buckets[host]; ok {
+		bucket.tokens = min(bucket.tokens+1, bucket.burst)
+	}
+}
+
+// tokenBucket refills rate tokens per second up to burst. Tokens may be reserved ahead, which drives the
+// number of tokens negative and makes later requests wait longer.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit HostLimit, now time.Time) *tokenBucket {
+	burst := float64(max(limit.Burst, 1))
+	return &tokenBucket{rate: limit.Rate, burst: burst, tokens: burst, last: now}
+}
+
+// reserve takes a token and returns how long to wait until it is available
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+	b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*b.rate, b.burst)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
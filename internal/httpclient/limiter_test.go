@@ -0,0 +1,119 @@
+package httpclient_test
+
+import (
+	"context"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/httpclient"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHostLimiter(t *testing.T) {
+	var mu sync.Mutex
+	var userAgents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		userAgents = append(userAgents, r.UserAgent())
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	// The default limit does not apply to hosts with a limit of their own
+	limiter := httpclient.NewHostLimiter(nil, httpclient.HostLimit{Rate: 0.001, Burst: 1})
+	limiter.SetLimit(serverURL.Host, httpclient.HostLimit{Rate: 20, Burst: 2})
+	client := &http.Client{Transport: limiter}
+
+	// Two requests pass at once, the other two are spaced 50ms apart
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Errorf("Expected no error, got %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected the requests to be limited, took %v", elapsed)
+	}
+
+	stats := limiter.Stats()[serverURL.Host]
+	if stats.Requests != 4 || stats.Delayed != 2 || stats.Waiting != 0 || stats.MaxWait < 90*time.Millisecond {
+		t.Errorf("Expected 4 requests with 2 delayed, got %+v", stats)
+	}
+	for _, userAgent := range userAgents {
+		if userAgent != httpclient.UserAgent {
+			t.Errorf("Expected %v, got %v", httpclient.UserAgent, userAgent)
+		}
+	}
+
+	// Requests that are cancelled while waiting give their token back
+	limiter.SetLimit(serverURL.Host, httpclient.HostLimit{Rate: 0.001, Burst: 1})
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := client.Do(req); err == nil {
+		t.Error("Expected the waiting request to be cancelled")
+	}
+	if stats := limiter.Stats()[serverURL.Host]; stats.Waiting != 0 || stats.Requests != 5 {
+		t.Errorf("Expected no waiting requests after 5 requests, got %+v", stats)
+	}
+}
+
+func TestParseHostLimit(t *testing.T) {
+	tests := map[string]httpclient.HostLimit{
+		"5":    {Rate: 5, Burst: 5},
+		"0.5":  {Rate: 0.5, Burst: 1},
+		"2:10": {Rate: 2, Burst: 10},
+		"0":    {Rate: 0, Burst: 1},
+	}
+	for value, expected := range tests {
+		limit, err := httpclient.ParseHostLimit(value)
+		if err != nil || limit != expected {
+			t.Errorf("Expected %v for %q, got %v (%v)", expected, value, limit, err)
+		}
+	}
+	for _, value := range []string{"", "fast", "-1", "5:0", "5:x"} {
+		if _, err := httpclient.ParseHostLimit(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestHostLimiterWaitsBeyondAttemptTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// The second request waits 100ms in the queue, longer than the transport waits for response headers
+	next := &http.Transport{ResponseHeaderTimeout: 50 * time.Millisecond}
+	client := &http.Client{Transport: httpclient.NewHostLimiter(next, httpclient.HostLimit{Rate: 10, Burst: 1})}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected the second request to be delayed, took %v", elapsed)
+	}
+}
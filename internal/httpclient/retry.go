@@ -0,0 +1,169 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryConfig configures the retries of a RetryTransport
+type RetryConfig struct {
+	// MaxRetries is the retry budget of a single request, 0 disables retries
+	MaxRetries int
+	// BaseDelay is the backoff before the first retry, it doubles with every further retry
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff
+	MaxDelay time.Duration
+	// MaxRetryAfter is the longest Retry-After of a 429 response that is waited for,
+	// responses that ask for longer are returned as they are
+	MaxRetryAfter time.Duration
+}
+
+// DefaultRetryConfig is used by DefaultHTTPClient unless the deployment configures otherwise
+var DefaultRetryConfig = RetryConfig{
+	MaxRetries:    3,
+	BaseDelay:     250 * time.Millisecond,
+	MaxDelay:      5 * time.Second,
+	MaxRetryAfter: 10 * time.Second,
+}
+
+// RetryFunc is called before every retry with the attempt that failed, the wait before the retry and why it failed
+type RetryFunc func(req *http.Request, attempt int, wait time.Duration, reason string)
+
+// RetryTransport retries idempotent requests that failed with a network error, a timeout or a 5xx status
+// with jittered exponential backoff. 429 responses are retried after their Retry-After.
+type RetryTransport struct {
+	Next   http.RoundTripper
+	Config RetryConfig
+	// OnRetry is called before every retry if it is set
+	OnRetry RetryFunc
+}
+
+// NewRetryTransport wraps next, a nil next uses http.DefaultTransport
+func NewRetryTransport(next http.RoundTripper, config RetryConfig) *RetryTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &RetryTransport{Next: next, Config: config}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	retryable := isIdempotent(req) && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			// A RoundTripper must not modify the request of the caller
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := t.Next.RoundTrip(attemptReq)
+		if !retryable || attempt >= t.Config.MaxRetries {
+			return resp, err
+		}
+		wait, reason, retry := t.retryAfter(req, resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+		if resp != nil {
+			// Drain the body so the connection can be reused
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		if t.OnRetry != nil {
+			t.OnRetry(req, attempt+1, wait, reason)
+		}
+		if waitErr := sleep(req.Context(), wait); waitErr != nil {
+			return nil, waitErr
+		}
+	}
+}
+
+// retryAfter decides whether a failed attempt is retried and how long to wait before
+func (t *RetryTransport) retryAfter(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, string, bool) {
+	if err != nil {
+		// Cancelled requests stay cancelled
+		if req.Context().Err() != nil || errors.Is(err, context.Canceled) {
+			return 0, "", false
+		}
+		return t.backoff(attempt), err.Error(), true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		wait, ok := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if !ok {
+			return t.backoff(attempt), resp.Status, true
+		}
+		return wait, resp.Status, wait <= t.Config.MaxRetryAfter
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return t.backoff(attempt), resp.Status, true
+	default:
+		return 0, "", false
+	}
+}
+
+// backoff returns the jittered exponential backoff before retry attempt+1,
+// a random duration between half and all of BaseDelay * 2^attempt capped at MaxDelay
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	delay := t.Config.BaseDelay << min(attempt, 30)
+	if t.Config.MaxDelay > 0 && (delay > t.Config.MaxDelay || delay <= 0) {
+		delay = t.Config.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// isIdempotent reports whether a request may be sent more than once
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	// Requests with an idempotency key are safe to repeat, like net/http does for its own retries
+	_, hasKey := req.Header["Idempotency-Key"]
+	_, hasXKey := req.Header["X-Idempotency-Key"]
+	return hasKey || hasXKey
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ParseRetryAfter parses a Retry-After header given in seconds or as HTTP date.
+// It reports false if the header is empty or invalid, waits in the past are returned as zero.
+func ParseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,219 @@
+package httpclient_test
+
+import (
+	"context"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/httpclient"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/olfake"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testRetryConfig = httpclient.RetryConfig{
+	MaxRetries:    3,
+	BaseDelay:     time.Millisecond,
+	MaxDelay:      5 * time.Millisecond,
+	MaxRetryAfter: time.Second,
+}
+
+// flakyServer answers the first failures requests with status and a Retry-After header and then with 200 OK
+func flakyServer(failures int32, status int, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	return server, &requests
+}
+
+func TestRetryTransportRetriesServerErrors(t *testing.T) {
+	server, requests := flakyServer(2, http.StatusServiceUnavailable, "")
+	defer server.Close()
+
+	var retries []int
+	transport := httpclient.NewRetryTransport(nil, testRetryConfig)
+	transport.OnRetry = func(req *http.Request, attempt int, wait time.Duration, reason string) {
+		retries = append(retries, attempt)
+	}
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	if requests.Load() != 3 || len(retries) != 2 {
+		t.Errorf("Expected 3 requests with 2 retries, got %d requests with %v", requests.Load(), retries)
+	}
+}
+
+func TestRetryTransportBudget(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+	server.Fail(olfake.AnyUser, http.StatusInternalServerError)
+
+	client := &http.Client{Transport: httpclient.NewRetryTransport(nil, testRetryConfig)}
+	resp, err := client.Get(server.URL() + "/apiv1/team/overview?userId=1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected %v, got %v", http.StatusInternalServerError, resp.StatusCode)
+	}
+	if server.OverviewRequests() != testRetryConfig.MaxRetries+1 {
+		t.Errorf("Expected %v, got %v", testRetryConfig.MaxRetries+1, server.OverviewRequests())
+	}
+}
+
+func TestRetryTransportRetryAfter(t *testing.T) {
+	server, requests := flakyServer(1, http.StatusTooManyRequests, "0")
+	defer server.Close()
+
+	client := &http.Client{Transport: httpclient.NewRetryTransport(nil, testRetryConfig)}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || requests.Load() != 2 {
+		t.Errorf("Expected 200 after 2 requests, got %d after %d", resp.StatusCode, requests.Load())
+	}
+
+	// Waits longer than MaxRetryAfter are left to the caller
+	server, requests = flakyServer(1, http.StatusTooManyRequests, "60")
+	defer server.Close()
+	resp, err = client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || requests.Load() != 1 {
+		t.Errorf("Expected 429 after 1 request, got %d after %d", resp.StatusCode, requests.Load())
+	}
+}
+
+func TestRetryTransportIdempotency(t *testing.T) {
+	server, requests := flakyServer(1, http.StatusBadGateway, "")
+	defer server.Close()
+
+	client := &http.Client{Transport: httpclient.NewRetryTransport(nil, testRetryConfig)}
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || requests.Load() != 1 {
+		t.Errorf("Expected a POST not to be retried, got %d after %d requests", resp.StatusCode, requests.Load())
+	}
+
+	// With an idempotency key the body is sent again
+	server, requests = flakyServer(1, http.StatusBadGateway, "")
+	defer server.Close()
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("body"))
+	req.Header.Set("Idempotency-Key", "1")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || requests.Load() != 2 {
+		t.Errorf("Expected 200 after 2 requests, got %d after %d", resp.StatusCode, requests.Load())
+	}
+
+	// Retries send copies, the request of the caller keeps its body
+	server, requests = flakyServer(1, http.StatusBadGateway, "")
+	defer server.Close()
+	req, _ = http.NewRequest(http.MethodPost, server.URL, strings.NewReader("body"))
+	req.Header.Set("Idempotency-Key", "1")
+	body := req.Body
+	resp, err = httpclient.NewRetryTransport(nil, testRetryConfig).RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if req.Body != body || requests.Load() != 2 {
+		t.Errorf("Expected the request to be left alone after 2 requests, got %d requests", requests.Load())
+	}
+}
+
+func TestRetryTransportCancel(t *testing.T) {
+	server, requests := flakyServer(10, http.StatusServiceUnavailable, "")
+	defer server.Close()
+
+	config := testRetryConfig
+	config.BaseDelay, config.MaxDelay = time.Minute, time.Minute
+	client := &http.Client{Transport: httpclient.NewRetryTransport(nil, config)}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	start := time.Now()
+	_, err := client.Do(req)
+	if err == nil || time.Since(start) > time.Second {
+		t.Errorf("Expected the backoff to end with the context, got %v after %v", err, time.Since(start))
+	}
+	if requests.Load() != 1 {
+		t.Errorf("Expected %v, got %v", 1, requests.Load())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 11, 20, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header   string
+		expected time.Duration
+		ok       bool
+	}{
+		{"", 0, false},
+		{"soon", 0, false},
+		{"120", 2 * time.Minute, true},
+		{"-5", 0, true},
+		{"Wed, 20 Nov 2024 20:00:30 GMT", 30 * time.Second, true},
+		{"Wed, 20 Nov 2024 19:00:00 GMT", 0, true},
+	}
+	for _, test := range tests {
+		got, ok := httpclient.ParseRetryAfter(test.header, now)
+		if got != test.expected || ok != test.ok {
+			t.Errorf("Expected %v, %v for %q, got %v, %v", test.expected, test.ok, test.header, got, ok)
+		}
+	}
+}
+
+func TestRetryTransportAttemptTimeout(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first attempt stalls longer than the attempt timeout
+		if requests.Add(1) == 1 {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	next := &http.Transport{ResponseHeaderTimeout: 50 * time.Millisecond}
+	client := &http.Client{Transport: httpclient.NewRetryTransport(next, testRetryConfig)}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || requests.Load() != 2 {
+		t.Errorf("Expected 200 after 2 requests, got %d after %d", resp.StatusCode, requests.Load())
+	}
+}
@@ -0,0 +1,133 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// CancelGrace is how long Shutdown waits for cancelled work to return before it gives up on it
+const CancelGrace = 5 * time.Second
+
+// Manager coordinates a graceful shutdown. Work like interactions or scheduled jobs is tracked while it runs,
+// Shutdown stops accepting new work, waits for the tracked work, cancels what is left after the timeout and
+// finally runs the shutdown hooks.
+type Manager struct {
+	logger   *logrus.Logger
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopping chan struct{}
+
+	mu      sync.Mutex
+	closing bool
+	running sync.WaitGroup
+	hooks   []hook
+}
+
+// hook is a named step of the shutdown
+type hook struct {
+	name string
+	fn   func() error
+}
+
+// NewManager returns a Manager that accepts work until Shutdown is called
+func NewManager(logger *logrus.Logger) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		logger:   logger,
+		ctx:      ctx,
+		cancel:   cancel,
+		stopping: make(chan struct{}),
+	}
+}
+
+// Context is the context of all tracked work. It is cancelled once Shutdown has waited for the timeout.
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+// Stopping is closed as soon as Shutdown is called, e.g. to stop starting new jobs
+func (m *Manager) Stopping() <-chan struct{} {
+	return m.stopping
+}
+
+// Acquire tracks a piece of work until release is called. ok is false once Shutdown has been called,
+// the work must not be started then.
+func (m *Manager) Acquire() (release func(), ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closing {
+		return nil, false
+	}
+	m.running.Add(1)
+	var once sync.Once
+	return func() { once.Do(m.running.Done) }, true
+}
+
+// Go runs fn in a tracked goroutine with the context of the Manager. It returns false without running fn
+// once Shutdown has been called.
+func (m *Manager) Go(fn func(ctx context.Context)) bool {
+	release, ok := m.Acquire()
+	if !ok {
+		return false
+	}
+	go func() {
+		defer release()
+		fn(m.ctx)
+	}()
+	return true
+}
+
+// OnShutdown registers a hook that runs after the tracked work has finished. Hooks run in the order they
+// were registered, even if earlier ones fail.
+func (m *Manager) OnShutdown(name string, fn func() error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{name: name, fn: fn})
+}
+
+// Shutdown stops accepting work and waits up to timeout for the tracked work to finish. Work that is still
+// running then is cancelled and given CancelGrace to return. Afterwards the hooks run. The returned error
+// reports work that did not return and failed hooks.
+func (m *Manager) Shutdown(timeout time.Duration) error {
+	m.mu.Lock()
+	if m.closing {
+		m.mu.Unlock()
+		return errors.New("shutdown already in progress")
+	}
+	m.closing = true
+	close(m.stopping)
+	hooks := m.hooks
+	m.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		m.running.Wait()
+		close(drained)
+	}()
+
+	var errs []error
+	select {
+	case <-drained:
+	case <-time.After(timeout):
+		m.logger.Warnf("Work still running after %s, cancelling it", timeout)
+		m.cancel()
+		select {
+		case <-drained:
+		case <-time.After(CancelGrace):
+			errs = append(errs, fmt.Errorf("work still running %s after cancelling it", CancelGrace))
+		}
+	}
+	m.cancel()
+
+	for _, h := range hooks {
+		m.logger.Infof("Shutdown: %s", h.name)
+		if err := h.fn(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
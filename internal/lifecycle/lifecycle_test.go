@@ -0,0 +1,85 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/lifecycle"
+	"io"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestManager() *lifecycle.Manager {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return lifecycle.NewManager(logger)
+}
+
+func TestShutdownDrains(t *testing.T) {
+	manager := newTestManager()
+	var steps []string
+	manager.OnShutdown("storage", func() error {
+		steps = append(steps, "storage")
+		return errors.New("closing failed")
+	})
+	manager.OnShutdown("session", func() error {
+		steps = append(steps, "session")
+		return nil
+	})
+
+	release, ok := manager.Acquire()
+	if !ok {
+		t.Fatal("Expected work to be accepted")
+	}
+	finished := false
+	go func() {
+		<-manager.Stopping()
+		time.Sleep(20 * time.Millisecond)
+		finished = true
+		release()
+	}()
+
+	err := manager.Shutdown(time.Second)
+	if !finished {
+		t.Error("Expected the shutdown to wait for the running work")
+	}
+	if manager.Context().Err() == nil {
+		t.Error("Expected the context to be cancelled after the shutdown")
+	}
+	// Failing hooks do not stop the following ones
+	if expected := []string{"storage", "session"}; !slices.Equal(steps, expected) {
+		t.Errorf("Expected %v, got %v", expected, steps)
+	}
+	if err == nil {
+		t.Error("Expected the error of the storage hook")
+	}
+
+	if _, ok := manager.Acquire(); ok {
+		t.Error("Expected no work to be accepted after the shutdown")
+	}
+	if manager.Go(func(context.Context) {}) {
+		t.Error("Expected no goroutine to be started after the shutdown")
+	}
+}
+
+func TestShutdownCancels(t *testing.T) {
+	manager := newTestManager()
+	var workErr error
+	manager.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		workErr = ctx.Err()
+	})
+
+	start := time.Now()
+	if err := manager.Shutdown(20 * time.Millisecond); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the work to be cancelled after the timeout, took %v", elapsed)
+	}
+	if !errors.Is(workErr, context.Canceled) {
+		t.Errorf("Expected %v, got %v", context.Canceled, workErr)
+	}
+}
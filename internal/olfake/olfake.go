@@ -0,0 +1,251 @@
+// Package olfake provides a local stand-in for the onlineliga API to test scraping without network access
+package olfake
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultOverview is a team overview of DefaultUserID with a last match, a next match and a league table
+//
+//go:embed files/overview.json
+var DefaultOverview []byte
+
+// DefaultUserID is the user of DefaultOverview
+const DefaultUserID = "8315"
+
+// OverviewFor returns DefaultOverview with DefaultUserID replaced by userID, so several users can be served
+func OverviewFor(userID string) []byte {
+	return bytes.ReplaceAll(DefaultOverview, []byte(DefaultUserID), []byte(userID))
+}
+
+// AnyUser applies a fault to every user that has no fault of its own
+const AnyUser = "*"
+
+// RetryAfter is the value of the Retry-After header of 429 responses
+const RetryAfter = "1"
+
+// malformedBody is returned for users with a malformed overview
+const malformedBody = `{"user": {"uid": 8315, "league": {"level": `
+
+// Server is a fake onlineliga API that serves /apiv1/team/overview from fixtures and badge images
+// below /badges/. Responses can be delayed and failed per user.
+type Server struct {
+	server *httptest.Server
+
+	mu        sync.Mutex
+	overviews map[string][]byte
+	statuses  map[string]int
+	malformed map[string]bool
+	latency   time.Duration
+	etags     bool
+
+	overviewRequests atomic.Int32
+	notModified      atomic.Int32
+	abortedRequests  atomic.Int32
+	badgeRequests    atomic.Int32
+}
+
+// NewServer starts a fake server that serves DefaultOverview for DefaultUserID. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		overviews: map[string][]byte{DefaultUserID: DefaultOverview},
+		statuses:  map[string]int{},
+		malformed: map[string]bool{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/apiv1/team/overview", s.handleOverview)
+	mux.HandleFunc("/badges/", s.handleBadge)
+	s.server = httptest.NewServer(mux)
+	return s
+}
+
+// URL returns the base URL of the server, it takes the place of getBaseURL
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Client returns an HTTP client that talks to the server
+func (s *Server) Client() *http.Client {
+	return s.server.Client()
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// BadgeURL returns the URL of a badge image served by the server
+func (s *Server) BadgeURL(name string) string {
+	return s.server.URL + "/badges/" + name + ".png"
+}
+
+// SetOverview serves body as the team overview of a user. Users without an overview get a 404.
+func (s *Server) SetOverview(userID string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overviews[userID] = body
+}
+
+// SetLatency delays every response by latency, requests that are cancelled meanwhile are aborted
+func (s *Server) SetLatency(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = latency
+}
+
+// SetETags makes the server send an ETag with every overview and answer requests with a matching
+// If-None-Match with 304 Not Modified
+func (s *Server) SetETags(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.etags = enabled
+}
+
+// Fail answers the overview requests of a user, or of AnyUser, with status, e.g. 404, 500 or 429.
+// 429 responses carry a Retry-After header. A status of 0 removes the fault.
+func (s *Server) Fail(userID string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status == 0 {
+		delete(s.statuses, userID)
+		return
+	}
+	s.statuses[userID] = status
+}
+
+// Malform answers the overview requests of a user, or of AnyUser, with truncated JSON
+func (s *Server) Malform(userID string, malformed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.malformed[userID] = malformed
+}
+
+// OverviewRequests returns the number of overview requests the server has received
+func (s *Server) OverviewRequests() int {
+	return int(s.overviewRequests.Load())
+}
+
+// NotModifiedResponses returns the number of overview requests that were answered with 304 Not Modified
+func (s *Server) NotModifiedResponses() int {
+	return int(s.notModified.Load())
+}
+
+// AbortedRequests returns the number of requests the client cancelled while the server delayed them
+func (s *Server) AbortedRequests() int {
+	return int(s.abortedRequests.Load())
+}
+
+// BadgeRequests returns the number of badge requests the server has received
+func (s *Server) BadgeRequests() int {
+	return int(s.badgeRequests.Load())
+}
+
+// handleOverview serves the team overview of the user in the userId query parameter
+func (s *Server) handleOverview(w http.ResponseWriter, r *http.Request) {
+	s.overviewRequests.Add(1)
+	if !s.wait(r) {
+		return
+	}
+	userID := r.URL.Query().Get("userId")
+
+	s.mu.Lock()
+	status, failed := s.statuses[userID]
+	if !failed {
+		status, failed = s.statuses[AnyUser]
+	}
+	malformed, ok := s.malformed[userID]
+	if !ok {
+		malformed = s.malformed[AnyUser]
+	}
+	body, found := s.overviews[userID]
+	etags := s.etags
+	s.mu.Unlock()
+
+	switch {
+	case failed:
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", RetryAfter)
+		}
+		http.Error(w, http.StatusText(status), status)
+	case malformed:
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(malformedBody))
+	case !found:
+		http.NotFound(w, r)
+	case etags:
+		etag := fmt.Sprintf(`"%x"`, sha256.Sum256(body))
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			s.notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}
+}
+
+// handleBadge serves a small PNG for every path below /badges/ that ends with .png
+func (s *Server) handleBadge(w http.ResponseWriter, r *http.Request) {
+	s.badgeRequests.Add(1)
+	if !s.wait(r) {
+		return
+	}
+	if !strings.HasSuffix(r.URL.Path, ".png") {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	_, _ = w.Write(badgePNG)
+}
+
+// wait delays the response by the configured latency and reports false if the request was cancelled meanwhile
+func (s *Server) wait(r *http.Request) bool {
+	s.mu.Lock()
+	latency := s.latency
+	s.mu.Unlock()
+	if latency <= 0 {
+		return true
+	}
+	timer := time.NewTimer(latency)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-r.Context().Done():
+		s.abortedRequests.Add(1)
+		return false
+	}
+}
+
+// badgePNG is the image of all badges, a filled square
+var badgePNG = encodeBadge()
+
+func encodeBadge() []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for x := 0; x < 16; x++ {
+		for y := 0; y < 16; y++ {
+			img.Set(x, y, color.RGBA{R: 30, G: 100, B: 220, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,185 @@
+package olfake_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/olfake"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// getOverview requests the team overview of userID and returns the status, the Retry-After header and the body
+func getOverview(t *testing.T, ctx context.Context, server *olfake.Server, userID string) (int, string, []byte, error) {
+	t.Helper()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL()+"/apiv1/team/overview?userId="+userID, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	response, err := server.Client().Do(request)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	return response.StatusCode, response.Header.Get("Retry-After"), body, nil
+}
+
+func TestServerOverview(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+	server.SetOverview("7370", olfake.OverviewFor("7370"))
+
+	ctx := context.Background()
+	for userID, expected := range map[string][]byte{olfake.DefaultUserID: olfake.DefaultOverview, "7370": olfake.OverviewFor("7370")} {
+		status, _, body, err := getOverview(t, ctx, server, userID)
+		if err != nil || status != http.StatusOK || !bytes.Equal(body, expected) {
+			t.Errorf("Expected the overview of %s, got %d (%v)", userID, status, err)
+		}
+	}
+
+	// Users without an overview are not found
+	if status, _, _, err := getOverview(t, ctx, server, "1234"); err != nil || status != http.StatusNotFound {
+		t.Errorf("Expected %d, got %d (%v)", http.StatusNotFound, status, err)
+	}
+	if server.OverviewRequests() != 3 {
+		t.Errorf("Expected 3 overview requests, got %d", server.OverviewRequests())
+	}
+}
+
+func TestServerFail(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+	server.SetOverview("7370", olfake.OverviewFor("7370"))
+
+	ctx := context.Background()
+	server.Fail(olfake.DefaultUserID, http.StatusInternalServerError)
+	status, retryAfter, _, err := getOverview(t, ctx, server, olfake.DefaultUserID)
+	if err != nil || status != http.StatusInternalServerError || retryAfter != "" {
+		t.Errorf("Expected %d without Retry-After, got %d with %q (%v)", http.StatusInternalServerError, status, retryAfter, err)
+	}
+	if status, _, _, err = getOverview(t, ctx, server, "7370"); err != nil || status != http.StatusOK {
+		t.Errorf("Expected other users to be served, got %d (%v)", status, err)
+	}
+
+	// Faults of AnyUser apply to users without a fault of their own, which takes precedence
+	server.Fail(olfake.AnyUser, http.StatusTooManyRequests)
+	status, retryAfter, _, err = getOverview(t, ctx, server, "7370")
+	if err != nil || status != http.StatusTooManyRequests || retryAfter != olfake.RetryAfter {
+		t.Errorf("Expected %d with Retry-After %q, got %d with %q (%v)", http.StatusTooManyRequests, olfake.RetryAfter, status, retryAfter, err)
+	}
+	if status, _, _, err = getOverview(t, ctx, server, olfake.DefaultUserID); err != nil || status != http.StatusInternalServerError {
+		t.Errorf("Expected %d, got %d (%v)", http.StatusInternalServerError, status, err)
+	}
+
+	// A status of 0 removes the faults again
+	server.Fail(olfake.AnyUser, 0)
+	server.Fail(olfake.DefaultUserID, 0)
+	for _, userID := range []string{olfake.DefaultUserID, "7370"} {
+		if status, _, _, err = getOverview(t, ctx, server, userID); err != nil || status != http.StatusOK {
+			t.Errorf("Expected %s to be served, got %d (%v)", userID, status, err)
+		}
+	}
+}
+
+func TestServerMalform(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+
+	ctx := context.Background()
+	server.Malform(olfake.AnyUser, true)
+	status, _, body, err := getOverview(t, ctx, server, olfake.DefaultUserID)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Expected %d, got %d (%v)", http.StatusOK, status, err)
+	}
+	var overview map[string]any
+	if err = json.Unmarshal(body, &overview); err == nil {
+		t.Errorf("Expected a malformed body, got %q", body)
+	}
+
+	// A user of its own overrides AnyUser
+	server.Malform(olfake.DefaultUserID, false)
+	if _, _, body, err = getOverview(t, ctx, server, olfake.DefaultUserID); err != nil || !bytes.Equal(body, olfake.DefaultOverview) {
+		t.Errorf("Expected the overview, got %q (%v)", body, err)
+	}
+}
+
+func TestServerLatency(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+
+	latency := 50 * time.Millisecond
+	server.SetLatency(latency)
+	start := time.Now()
+	if status, _, _, err := getOverview(t, context.Background(), server, olfake.DefaultUserID); err != nil || status != http.StatusOK {
+		t.Errorf("Expected %d, got %d (%v)", http.StatusOK, status, err)
+	}
+	if elapsed := time.Since(start); elapsed < latency {
+		t.Errorf("Expected the response to take at least %v, got %v", latency, elapsed)
+	}
+
+	// Requests that are cancelled while they are delayed are aborted
+	server.SetLatency(time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, _, err := getOverview(t, ctx, server, olfake.DefaultUserID); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for server.AbortedRequests() != 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if server.AbortedRequests() != 1 {
+		t.Errorf("Expected 1 aborted request, got %d", server.AbortedRequests())
+	}
+}
+
+func TestServerETags(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+	server.SetETags(true)
+
+	url := server.URL() + "/apiv1/team/overview?userId=" + olfake.DefaultUserID
+	response, err := server.Client().Get(url)
+	if err != nil {
+		t.Fatalf("Failed to get the overview: %v", err)
+	}
+	response.Body.Close()
+	etag := response.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected an ETag")
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, url, nil)
+	request.Header.Set("If-None-Match", etag)
+	if response, err = server.Client().Do(request); err != nil {
+		t.Fatalf("Failed to get the overview: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNotModified || server.NotModifiedResponses() != 1 {
+		t.Errorf("Expected %d, got %d after %d not modified responses", http.StatusNotModified, response.StatusCode, server.NotModifiedResponses())
+	}
+}
+
+func TestServerBadge(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+
+	response, err := server.Client().Get(server.BadgeURL("1"))
+	if err != nil {
+		t.Fatalf("Failed to get the badge: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK || response.Header.Get("Content-Type") != "image/png" {
+		t.Errorf("Expected a PNG, got %d %q", response.StatusCode, response.Header.Get("Content-Type"))
+	}
+	if server.BadgeRequests() != 1 {
+		t.Errorf("Expected 1 badge request, got %d", server.BadgeRequests())
+	}
+}
@@ -1,5 +1,7 @@
 package parse
 
+import "time"
+
 // Root is the root object that contains all the data
 type Root struct {
 	MatchData    MatchData     `json:"matchData"`
@@ -41,6 +43,7 @@ type League struct {
 // MatchData is the root object that contains data about the last match, next match, etc.
 type MatchData struct {
 	LastMatch Match `json:"lastMatch"`
+	NextMatch Match `json:"nextMatch"`
 }
 
 // Match contains details about a match (nested in MatchData)
@@ -104,4 +107,21 @@ type MatchResult struct {
 	MatchState     string `json:"matchState"`
 	AwayTeam       string `json:"awayTeam"`
 	Points         string `json:"points"`
+	MatchID        int    `json:"matchId"`
+	Matchday       int    `json:"matchday"`
+}
+
+// UpcomingMatch is the struct that contains the formatted next match of a user
+// that will be displayed in the output
+type UpcomingMatch struct {
+	LeagueLevel      string    `json:"leagueLevel"`
+	Team             string    `json:"team"`
+	BadgeURL         string    `json:"badgeURL"`
+	Opponent         string    `json:"opponent"`
+	OpponentBadgeURL string    `json:"opponentBadgeURL"`
+	Home             bool      `json:"home"`
+	Competition      string    `json:"competition"`
+	Kickoff          time.Time `json:"kickoff"`
+	MatchID          int       `json:"matchId"`
+	Matchday         int       `json:"matchday"`
 }
@@ -0,0 +1,79 @@
+package parse
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// matchTypes maps the MatchTypeID of a match to the name of its competition. Only ids seen in
+// overviews are listed, others are shown with their number.
+var matchTypes = map[int]string{
+	1: "League",
+}
+
+// KickoffLocation is the time zone of onlineliga, it runs on german time
+var KickoffLocation = loadKickoffLocation()
+
+func loadKickoffLocation() *time.Location {
+	location, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// NextMatchFromRoot returns the upcoming match of a user from a decoded overview page
+func NextMatchFromRoot(rootObject Root, userID int) (UpcomingMatch, error) {
+	nextMatch := rootObject.MatchData.NextMatch
+	if nextMatch.MatchID == 0 {
+		return UpcomingMatch{}, &ResultError{Msg: "Error: No upcoming match found for user " + strconv.Itoa(userID)}
+	}
+
+	// The user is either the home (A) or the away (B) team
+	team, opponent := nextMatch.UserA, nextMatch.UserB
+	home := team.UID == userID
+	if !home {
+		team, opponent = opponent, team
+	}
+
+	return UpcomingMatch{
+		LeagueLevel:      fmt.Sprintf("OL%d", rootObject.User.League.Level),
+		Team:             team.TeamName,
+		BadgeURL:         team.Badge.URL,
+		Opponent:         opponent.TeamName,
+		OpponentBadgeURL: opponent.Badge.URL,
+		Home:             home,
+		Competition:      competitionName(nextMatch.MatchTypeID),
+		Kickoff:          nextMatch.Kickoff(),
+		MatchID:          nextMatch.MatchID,
+		Matchday:         nextMatch.Matchday,
+	}, nil
+}
+
+// competitionName returns the name of the competition of a match type
+func competitionName(matchTypeID int) string {
+	if name, ok := matchTypes[matchTypeID]; ok {
+		return name
+	}
+	return fmt.Sprintf("Match type %d", matchTypeID)
+}
+
+// Kickoff returns the kickoff time of a match or the zero time if it is unknown
+func (m Match) Kickoff() time.Time {
+	return parseTimestamp(m.Timestamp)
+}
+
+// parseTimestamp parses the kickoff timestamp of a match, which is either given in unix seconds or
+// as a date time string. Date times without a zone are in KickoffLocation. Unknown formats result in the zero time.
+func parseTimestamp(timestamp string) time.Time {
+	if seconds, err := strconv.ParseInt(timestamp, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC()
+	}
+	for _, layout := range []string{time.RFC3339, time.DateTime} {
+		if kickoff, err := time.ParseInLocation(layout, timestamp, KickoffLocation); err == nil {
+			return kickoff
+		}
+	}
+	return time.Time{}
+}
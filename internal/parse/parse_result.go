@@ -41,12 +41,22 @@ func Result(responseBody []byte, userID int) ([]string, error) {
 
 // ResultObject gets the overview page of a user and returns the result of the last match as a MatchResult struct
 func ResultObject(responseBody []byte, userID int) (MatchResult, error) {
-	var rootObject Root
-	marshalErr := json.Unmarshal(responseBody, &rootObject)
+	rootObject, marshalErr := Overview(responseBody)
 	if marshalErr != nil {
 		return MatchResult{}, marshalErr
 	}
+	return ResultFromRoot(rootObject, userID)
+}
+
+// Overview decodes the overview page of a user into a Root object
+func Overview(responseBody []byte) (Root, error) {
+	var rootObject Root
+	marshalErr := json.Unmarshal(responseBody, &rootObject)
+	return rootObject, marshalErr
+}
 
+// ResultFromRoot returns the result of the last match of a decoded overview page as a MatchResult struct
+func ResultFromRoot(rootObject Root, userID int) (MatchResult, error) {
 	// The league tables contain a league table for each team in the league
 	// And we need to filter the league table for the given user id
 	leagueTable, filterErr := filterLeagueTableForTeam(rootObject.LeagueTables, userID)
@@ -64,6 +74,8 @@ func ResultObject(responseBody []byte, userID int) (MatchResult, error) {
 		MatchState:     getMatchState(&rootObject.MatchData.LastMatch, userID),
 		AwayTeam:       rootObject.MatchData.LastMatch.UserB.TeamName,
 		Points:         fmt.Sprintf("%d pts", leagueTable.Points),
+		MatchID:        rootObject.MatchData.LastMatch.MatchID,
+		Matchday:       rootObject.MatchData.LastMatch.Matchday,
 	}
 
 	return result, nil
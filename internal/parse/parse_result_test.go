@@ -1,18 +1,15 @@
 package parse_test
 
 import (
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/olfake"
 	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
-	"os"
 	"testing"
+	"time"
 )
 
 func TestResult(t *testing.T) {
-	// open file which is located in files path
-	path := "files/parse_test.json"
-	jsonData, err := os.ReadFile(path)
-	if err != nil {
-		t.Errorf("Failed to read file: %v", err)
-	}
+	// The overview fixture that olfake serves, so both test the same data
+	jsonData := olfake.DefaultOverview
 
 	userID := 8315
 	result, parseErr := parse.ResultObject(jsonData, userID)
@@ -21,6 +18,7 @@ func TestResult(t *testing.T) {
 	}
 
 	expectedResult := parse.MatchResult{
+		LeagueInfo:     parse.League{Level: 1},
 		LeagueLevel:    "OL1",
 		BadgeURL:       "https://bla.xyz/image/1.png",
 		LeaguePosition: "#11",
@@ -29,6 +27,8 @@ func TestResult(t *testing.T) {
 		MatchState:     "WIN",
 		AwayTeam:       "Test B",
 		Points:         "3 pts",
+		MatchID:        1878,
+		Matchday:       2,
 	}
 
 	if result != expectedResult {
@@ -36,3 +36,54 @@ func TestResult(t *testing.T) {
 	}
 
 }
+
+func TestNextMatch(t *testing.T) {
+	rootObject, err := parse.Overview(olfake.DefaultOverview)
+	if err != nil {
+		t.Fatalf("Failed to decode overview: %v", err)
+	}
+
+	userID := 8315
+	nextMatch, parseErr := parse.NextMatchFromRoot(rootObject, userID)
+	if parseErr != nil {
+		t.Errorf("Failed to parse next match: %v", parseErr)
+	}
+
+	expectedMatch := parse.UpcomingMatch{
+		LeagueLevel:      "OL1",
+		Team:             "Test A",
+		BadgeURL:         "https://bla.xyz/image/1.png",
+		Opponent:         "Test C",
+		OpponentBadgeURL: "https://bla.xyz/image/3.png",
+		Home:             false,
+		Competition:      "League",
+		Kickoff:          time.Unix(1732132800, 0).UTC(),
+		MatchID:          1895,
+		Matchday:         3,
+	}
+
+	if nextMatch != expectedMatch {
+		t.Errorf("Expected %v, got %v", expectedMatch, nextMatch)
+	}
+}
+
+func TestMatchKickoff(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Time zone data is missing: %v", err)
+	}
+
+	tests := map[string]time.Time{
+		"1732132800":                time.Unix(1732132800, 0).UTC(),
+		"2024-11-20T21:00:00+01:00": time.Date(2024, 11, 20, 20, 0, 0, 0, time.UTC),
+		// Date times without a zone are german time, in summer and in winter
+		"2024-11-20 21:00:00": time.Date(2024, 11, 20, 21, 0, 0, 0, berlin),
+		"2024-07-10 18:30:00": time.Date(2024, 7, 10, 16, 30, 0, 0, time.UTC),
+		"tomorrow":            {},
+	}
+	for timestamp, expected := range tests {
+		if kickoff := (parse.Match{Timestamp: timestamp}).Kickoff(); !kickoff.Equal(expected) {
+			t.Errorf("Expected %v for %q, got %v", expected, timestamp, kickoff)
+		}
+	}
+}
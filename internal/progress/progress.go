@@ -0,0 +1,64 @@
+// Package progress shows how far a long running scrape got by editing a message while the scrape runs
+package progress
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EditFunc replaces the content of the message that shows the progress, e.g. a deferred interaction response
+type EditFunc func(content string)
+
+// Reporter edits the progress of a multi user scrape into a message. Edits are throttled and run in the
+// background so they never slow down the scrape.
+type Reporter struct {
+	edit     EditFunc
+	header   string
+	interval time.Duration
+
+	mu       sync.Mutex
+	lastEdit time.Time
+	inFlight bool
+	stopped  bool
+	wg       sync.WaitGroup
+}
+
+// NewReporter returns a Reporter that shows header above the progress line and edits at most once per interval
+func NewReporter(edit EditFunc, header string, interval time.Duration) *Reporter {
+	return &Reporter{
+		edit:     edit,
+		header:   header,
+		interval: interval,
+	}
+}
+
+// Report implements scraper.ProgressFunc. Progress that arrives while an edit is running or within the
+// interval after the last one is skipped.
+func (r *Reporter) Report(done int, total int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped || r.inFlight || time.Since(r.lastEdit) < r.interval {
+		return
+	}
+	r.inFlight = true
+	r.lastEdit = time.Now()
+
+	content := fmt.Sprintf("%s\nfetched %d/%d managers…", r.header, done, total)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.edit(content)
+		r.mu.Lock()
+		r.inFlight = false
+		r.mu.Unlock()
+	}()
+}
+
+// Stop prevents further progress edits and waits for a running one, so it cannot overwrite the final answer
+func (r *Reporter) Stop() {
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+	r.wg.Wait()
+}
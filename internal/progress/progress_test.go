@@ -0,0 +1,100 @@
+package progress_test
+
+import (
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/progress"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMessage records the edits of a message, edits can be held back to keep them in flight
+type fakeMessage struct {
+	mu       sync.Mutex
+	contents []string
+	release  chan struct{}
+}
+
+func (m *fakeMessage) edit(content string) {
+	if m.release != nil {
+		<-m.release
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.contents = append(m.contents, content)
+}
+
+func (m *fakeMessage) edits() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.contents...)
+}
+
+func TestReporterThrottles(t *testing.T) {
+	message := &fakeMessage{}
+	reporter := progress.NewReporter(message.edit, "header", time.Hour)
+	for done := 1; done <= 50; done++ {
+		reporter.Report(done, 50)
+	}
+	reporter.Stop()
+
+	// Only the first progress is shown within the interval
+	edits := message.edits()
+	if len(edits) != 1 || edits[0] != "header\nfetched 1/50 managers…" {
+		t.Errorf("Expected a single edit of the first progress, got %q", edits)
+	}
+}
+
+func TestReporterSkipsWhileInFlight(t *testing.T) {
+	message := &fakeMessage{release: make(chan struct{})}
+	reporter := progress.NewReporter(message.edit, "header", 0)
+
+	// Progress is dropped while an edit is running instead of queueing up behind it
+	reporter.Report(1, 3)
+	reporter.Report(2, 3)
+	close(message.release)
+	reporter.Stop()
+
+	if edits := message.edits(); len(edits) != 1 || edits[0] != "header\nfetched 1/3 managers…" {
+		t.Errorf("Expected a single edit of the first progress, got %q", edits)
+	}
+}
+
+func TestReporterStop(t *testing.T) {
+	message := &fakeMessage{release: make(chan struct{})}
+	reporter := progress.NewReporter(message.edit, "header", 0)
+
+	// Progress reported from several scrape workers at once
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for done := 1; done <= 20; done++ {
+				reporter.Report(done, 20)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stopped := make(chan struct{})
+	go func() {
+		reporter.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("Expected Stop to wait for the running edit")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(message.release)
+	<-stopped
+
+	// The final answer is the last edit, progress reported after Stop never overwrites it
+	message.edit("final")
+	reporter.Report(20, 20)
+	time.Sleep(10 * time.Millisecond)
+	edits := message.edits()
+	if len(edits) != 2 || edits[len(edits)-1] != "final" {
+		t.Errorf("Expected one progress edit followed by the final answer, got %q", edits)
+	}
+}
@@ -0,0 +1,219 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+	"sync"
+)
+
+// API is the part of the discord session the registry uses, *discordgo.Session implements it
+type API interface {
+	ApplicationCommands(appID, guildID string, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error)
+	ApplicationCommandBulkOverwrite(appID string, guildID string, commands []*discordgo.ApplicationCommand, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error)
+}
+
+// Registry keeps the application commands of the bot registered either globally or in every guild.
+// Registrations are only overwritten if they differ from the commands, so reconnects and restarts do not
+// cause needless requests.
+type Registry struct {
+	api      API
+	logger   *logrus.Logger
+	commands []*discordgo.ApplicationCommand
+	global   bool
+
+	mu     sync.Mutex
+	synced map[string]bool
+}
+
+// NewRegistry returns a Registry for commands. With global the commands are published for all guilds at once,
+// which takes discord a while to roll out, otherwise they are registered per guild.
+func NewRegistry(api API, logger *logrus.Logger, commands []*discordgo.ApplicationCommand, global bool) *Registry {
+	return &Registry{
+		api:      api,
+		logger:   logger,
+		commands: commands,
+		global:   global,
+		synced:   map[string]bool{},
+	}
+}
+
+// OnReady syncs the global commands once the session is ready, register it with discordgo.Session.AddHandler
+func (r *Registry) OnReady(s *discordgo.Session, e *discordgo.Ready) {
+	if err := r.SyncGlobal(e.User.ID); err != nil {
+		r.logger.WithError(err).Error("Error registering global commands")
+	}
+}
+
+// OnGuildCreate syncs the commands of a guild the bot joined or that became available,
+// register it with discordgo.Session.AddHandler
+func (r *Registry) OnGuildCreate(s *discordgo.Session, e *discordgo.GuildCreate) {
+	if err := r.SyncGuild(s.State.User.ID, e.ID); err != nil {
+		r.logger.WithError(err).Errorf("Error registering commands for guild %s", e.Name)
+	}
+}
+
+// OnGuildDelete forgets a guild the bot was removed from, register it with discordgo.Session.AddHandler.
+// Discord drops the commands of the guild itself. Guilds that are only unavailable during an outage are kept.
+func (r *Registry) OnGuildDelete(s *discordgo.Session, e *discordgo.GuildDelete) {
+	if e.Unavailable {
+		return
+	}
+	r.Forget(e.ID)
+}
+
+// SyncGlobal registers the global commands, or removes them if the commands are registered per guild
+func (r *Registry) SyncGlobal(appID string) error {
+	var commands []*discordgo.ApplicationCommand
+	if r.global {
+		commands = r.commands
+	}
+	return r.sync(appID, "", commands)
+}
+
+// SyncGuild registers the commands of a guild, or removes them if the commands are registered globally.
+// Guilds that have been synced before are skipped until they are forgotten.
+func (r *Registry) SyncGuild(appID string, guildID string) error {
+	r.mu.Lock()
+	synced := r.synced[guildID]
+	r.mu.Unlock()
+	if synced {
+		return nil
+	}
+
+	var commands []*discordgo.ApplicationCommand
+	if !r.global {
+		commands = r.commands
+	}
+	if err := r.sync(appID, guildID, commands); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.synced[guildID] = true
+	r.mu.Unlock()
+	return nil
+}
+
+// Forget makes the next SyncGuild of a guild check its commands again
+func (r *Registry) Forget(guildID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.synced, guildID)
+}
+
+// sync overwrites the registered commands of a guild, or the global ones for an empty guildID,
+// if they differ from commands
+func (r *Registry) sync(appID string, guildID string, commands []*discordgo.ApplicationCommand) error {
+	scope := "global"
+	if guildID != "" {
+		scope = "guild " + guildID
+	}
+
+	registered, err := r.api.ApplicationCommands(appID, guildID)
+	if err != nil {
+		return err
+	}
+	if equal(registered, commands) {
+		r.logger.Debugf("Commands of %s are up to date", scope)
+		return nil
+	}
+
+	// Bulk overwriting with an empty list removes all commands
+	if commands == nil {
+		commands = []*discordgo.ApplicationCommand{}
+	}
+	if _, err = r.api.ApplicationCommandBulkOverwrite(appID, guildID, commands); err != nil {
+		return err
+	}
+	r.logger.Infof("Registered %d commands for %s", len(commands), scope)
+	return nil
+}
+
+// equal reports whether registered commands as returned by discord match the wanted commands.
+// Ids, versions and the defaults discord fills in are ignored.
+func equal(registered []*discordgo.ApplicationCommand, wanted []*discordgo.ApplicationCommand) bool {
+	if len(registered) != len(wanted) {
+		return false
+	}
+	wantedByName := make(map[string]*discordgo.ApplicationCommand, len(wanted))
+	for _, command := range wanted {
+		wantedByName[command.Name] = command
+	}
+	for _, command := range registered {
+		wantedCommand, ok := wantedByName[command.Name]
+		if !ok {
+			return false
+		}
+		registeredJSON, err := json.Marshal(normalize(command, wantedCommand))
+		if err != nil {
+			return false
+		}
+		wantedJSON, err := json.Marshal(normalize(wantedCommand, wantedCommand))
+		if err != nil {
+			return false
+		}
+		if !bytes.Equal(registeredJSON, wantedJSON) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize returns a copy of command without the fields discord sets on registration and with the
+// defaults discord fills in for fields that wanted leaves empty
+func normalize(command *discordgo.ApplicationCommand, wanted *discordgo.ApplicationCommand) discordgo.ApplicationCommand {
+	normalized := discordgo.ApplicationCommand{
+		Type:                     command.Type,
+		Name:                     command.Name,
+		NameLocalizations:        emptyLocalizations(command.NameLocalizations),
+		DefaultMemberPermissions: command.DefaultMemberPermissions,
+		Description:              command.Description,
+		DescriptionLocalizations: emptyLocalizations(command.DescriptionLocalizations),
+		Options:                  normalizeOptions(command.Options),
+	}
+	if normalized.Type == 0 {
+		normalized.Type = discordgo.ChatApplicationCommand
+	}
+	if wanted.DMPermission != nil {
+		normalized.DMPermission = command.DMPermission
+	}
+	if command.NSFW != nil && *command.NSFW {
+		normalized.NSFW = command.NSFW
+	}
+	return normalized
+}
+
+// normalizeOptions copies options with empty slices and maps set to nil
+func normalizeOptions(options []*discordgo.ApplicationCommandOption) []*discordgo.ApplicationCommandOption {
+	if len(options) == 0 {
+		return nil
+	}
+	normalized := make([]*discordgo.ApplicationCommandOption, 0, len(options))
+	for _, option := range options {
+		copied := *option
+		copied.Options = normalizeOptions(option.Options)
+		if len(copied.Choices) == 0 {
+			copied.Choices = nil
+		}
+		if len(copied.ChannelTypes) == 0 {
+			copied.ChannelTypes = nil
+		}
+		if len(copied.NameLocalizations) == 0 {
+			copied.NameLocalizations = nil
+		}
+		if len(copied.DescriptionLocalizations) == 0 {
+			copied.DescriptionLocalizations = nil
+		}
+		normalized = append(normalized, &copied)
+	}
+	return normalized
+}
+
+// emptyLocalizations returns nil for missing or empty localizations
+func emptyLocalizations(localizations *map[discordgo.Locale]string) *map[discordgo.Locale]string {
+	if localizations == nil || len(*localizations) == 0 {
+		return nil
+	}
+	return localizations
+}
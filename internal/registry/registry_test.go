@@ -0,0 +1,154 @@
+package registry_test
+
+import (
+	"encoding/json"
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/registry"
+	"io"
+	"strconv"
+	"testing"
+)
+
+// fakeAPI keeps the registered commands per guild, "" holds the global ones
+type fakeAPI struct {
+	registered map[string][]*discordgo.ApplicationCommand
+	overwrites map[string]int
+}
+
+func newFakeAPI() *fakeAPI {
+	return &fakeAPI{registered: map[string][]*discordgo.ApplicationCommand{}, overwrites: map[string]int{}}
+}
+
+func (f *fakeAPI) ApplicationCommands(appID, guildID string, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error) {
+	return f.registered[guildID], nil
+}
+
+// ApplicationCommandBulkOverwrite stores the commands the way discord returns them, with ids and defaults
+func (f *fakeAPI) ApplicationCommandBulkOverwrite(appID string, guildID string, commands []*discordgo.ApplicationCommand, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error) {
+	f.overwrites[guildID]++
+	data, err := json.Marshal(commands)
+	if err != nil {
+		return nil, err
+	}
+	var registered []*discordgo.ApplicationCommand
+	if err = json.Unmarshal(data, &registered); err != nil {
+		return nil, err
+	}
+	dmPermission := true
+	for i, command := range registered {
+		command.ID = strconv.Itoa(i + 1)
+		command.ApplicationID = appID
+		command.GuildID = guildID
+		command.Version = "1"
+		command.Type = discordgo.ChatApplicationCommand
+		command.DMPermission = &dmPermission
+		if command.Options == nil {
+			command.Options = []*discordgo.ApplicationCommandOption{}
+		}
+	}
+	f.registered[guildID] = registered
+	return registered, nil
+}
+
+var manageGuild int64 = discordgo.PermissionManageServer
+
+var testCommands = []*discordgo.ApplicationCommand{
+	{
+		Name:        "results",
+		Description: "Get the results of a user",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "location",
+				Description: "The location",
+				Choices:     []*discordgo.ApplicationCommandOptionChoice{{Name: ".de", Value: ".de"}},
+			},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "users", Description: "User ids", Autocomplete: true},
+		},
+	},
+	{
+		Name:                     "watch",
+		Description:              "Manage the watchlist",
+		DefaultMemberPermissions: &manageGuild,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "list", Description: "List the watchlist"},
+		},
+	},
+	{Name: "unlink", Description: "Unlink your account"},
+}
+
+func newTestRegistry(api registry.API, global bool) *registry.Registry {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return registry.NewRegistry(api, logger, testCommands, global)
+}
+
+func TestRegistryGuildCommands(t *testing.T) {
+	api := newFakeAPI()
+	commands := newTestRegistry(api, false)
+	for i := 0; i < 2; i++ {
+		if err := commands.SyncGlobal("app"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err := commands.SyncGuild("app", "guild"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if api.overwrites["guild"] != 1 || api.overwrites[""] != 0 {
+		t.Errorf("Expected only 1 overwrite of the guild, got %v", api.overwrites)
+	}
+
+	// A restart finds the commands registered with all defaults filled in
+	commands = newTestRegistry(api, false)
+	if err := commands.SyncGuild("app", "guild"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if api.overwrites["guild"] != 1 {
+		t.Errorf("Expected the registered commands to be up to date, got %d overwrites", api.overwrites["guild"])
+	}
+
+	// Changed commands are overwritten once the guild is checked again
+	api.registered["guild"][0].Description = "Outdated"
+	if err := commands.SyncGuild("app", "guild"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	commands.OnGuildDelete(nil, &discordgo.GuildDelete{Guild: &discordgo.Guild{ID: "guild", Unavailable: true}})
+	if err := commands.SyncGuild("app", "guild"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if api.overwrites["guild"] != 1 {
+		t.Errorf("Expected synced guilds to be skipped, got %d overwrites", api.overwrites["guild"])
+	}
+	commands.OnGuildDelete(nil, &discordgo.GuildDelete{Guild: &discordgo.Guild{ID: "guild"}})
+	if err := commands.SyncGuild("app", "guild"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if api.overwrites["guild"] != 2 || api.registered["guild"][0].Description != testCommands[0].Description {
+		t.Errorf("Expected the changed command to be overwritten, got %d overwrites", api.overwrites["guild"])
+	}
+}
+
+func TestRegistryGlobalCommands(t *testing.T) {
+	api := newFakeAPI()
+	if err := newTestRegistry(api, false).SyncGuild("app", "guild"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Switching to global commands removes the guild commands so they are not listed twice
+	commands := newTestRegistry(api, true)
+	for i := 0; i < 2; i++ {
+		if err := commands.SyncGlobal("app"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if err := commands.SyncGuild("app", "guild"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(api.registered[""]) != len(testCommands) || api.overwrites[""] != 1 {
+		t.Errorf("Expected %d global commands from 1 overwrite, got %d from %d", len(testCommands), len(api.registered[""]), api.overwrites[""])
+	}
+	if len(api.registered["guild"]) != 0 || api.overwrites["guild"] != 2 {
+		t.Errorf("Expected the guild commands to be removed, got %d", len(api.registered["guild"]))
+	}
+}
@@ -0,0 +1,89 @@
+// Package resolver turns the user tokens of commands, user ids, names and discord mentions, into onlineliga user ids
+package resolver
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/scraper"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/storage"
+	"regexp"
+	"strconv"
+)
+
+// mentionPattern matches discord user mentions like <@123> or <@!123>
+var mentionPattern = regexp.MustCompile(`^<@!?(\d+)>$`)
+
+// BaseURLFunc maps a location like ".de" to the base url of the onlineliga instance
+type BaseURLFunc func(location string) string
+
+// UnresolvedError is returned for user tokens that could not be resolved to an onlineliga user id
+type UnresolvedError struct {
+	token  string
+	reason string
+}
+
+func (e *UnresolvedError) Error() string {
+	return "Error: " + e.token + " could not be resolved (" + e.reason + ")"
+}
+
+// Reason implements the user-facing explanation used by scraper.ErrorReason
+func (e *UnresolvedError) Reason() string {
+	return e.reason
+}
+
+// Resolver looks up names of known managers and the managers linked to discord users in the store
+type Resolver struct {
+	store   *storage.Store
+	logger  *logrus.Logger
+	baseURL BaseURLFunc
+}
+
+// NewResolver returns a new Resolver that finds the known managers of a location below baseURL(location)
+func NewResolver(store *storage.Store, logger *logrus.Logger, baseURL BaseURLFunc) *Resolver {
+	return &Resolver{store: store, logger: logger, baseURL: baseURL}
+}
+
+// Resolve turns user tokens into onlineliga user ids of location. Numeric ids are kept, discord mentions are
+// replaced with the linked manager and everything else is looked up as username or team name of a known manager.
+// Tokens that cannot be resolved, including mentions of managers linked at another location, are returned as
+// failed results.
+func (r *Resolver) Resolve(location string, tokens []string) ([]string, []scraper.UserResult) {
+	baseURL := r.baseURL(location)
+	var userIDs []string
+	var unresolved []scraper.UserResult
+	fail := func(token string, reason string) {
+		unresolved = append(unresolved, scraper.UserResult{UserID: token, Err: &UnresolvedError{token: token, reason: reason}})
+	}
+	for _, token := range tokens {
+		if _, err := strconv.Atoi(token); err == nil {
+			userIDs = append(userIDs, token)
+			continue
+		}
+
+		if match := mentionPattern.FindStringSubmatch(token); match != nil {
+			link, found, err := r.store.Link(match[1])
+			if err != nil {
+				r.logger.WithError(err).Errorf("Loading the link of %s failed", token)
+			}
+			switch {
+			case err != nil || !found:
+				fail(token, "not linked")
+			case link.Location != location:
+				fail(token, "linked to "+link.Location)
+			default:
+				userIDs = append(userIDs, link.UserID)
+			}
+			continue
+		}
+
+		manager, found, err := r.store.ManagerByName(baseURL, token)
+		if err != nil {
+			r.logger.WithError(err).Errorf("Looking up the manager %s failed", token)
+		}
+		if err != nil || !found {
+			fail(token, "unknown name")
+			continue
+		}
+		userIDs = append(userIDs, strconv.Itoa(manager.UserID))
+	}
+	return userIDs, unresolved
+}
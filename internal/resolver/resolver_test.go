@@ -0,0 +1,79 @@
+package resolver_test
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/resolver"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/scraper"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/storage"
+	"io"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func newTestResolver(t *testing.T) (*resolver.Resolver, *storage.Store) {
+	store, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	baseURL := func(location string) string { return "https://onlineliga" + location }
+	return resolver.NewResolver(store, logger, baseURL), store
+}
+
+func TestResolve(t *testing.T) {
+	userResolver, store := newTestResolver(t)
+	if err := store.SetLink("111", storage.Link{Location: ".de", UserID: "8315"}); err != nil {
+		t.Fatalf("Failed to store link: %v", err)
+	}
+	if err := store.SetLink("222", storage.Link{Location: ".co.uk", UserID: "7370"}); err != nil {
+		t.Fatalf("Failed to store link: %v", err)
+	}
+	if err := store.RememberManagers("https://onlineliga.de", []storage.Manager{{UserID: 5120, Username: "tester", TeamName: "Test C"}}); err != nil {
+		t.Fatalf("Failed to store manager: %v", err)
+	}
+
+	tokens := []string{"1234", "<@111>", "<@!111>", "Test C", "TESTER", "<@222>", "<@333>", "Unknown", "<@abc>"}
+	userIDs, unresolved := userResolver.Resolve(".de", tokens)
+
+	expectedUserIDs := []string{"1234", "8315", "8315", "5120", "5120"}
+	if !slices.Equal(userIDs, expectedUserIDs) {
+		t.Errorf("Expected %v, got %v", expectedUserIDs, userIDs)
+	}
+	expectedReasons := map[string]string{
+		"<@222>":  "linked to .co.uk",
+		"<@333>":  "not linked",
+		"Unknown": "unknown name",
+		"<@abc>":  "unknown name",
+	}
+	if len(unresolved) != len(expectedReasons) {
+		t.Errorf("Expected %d unresolved users, got %v", len(expectedReasons), unresolved)
+	}
+	for _, userResult := range unresolved {
+		if reason := scraper.ErrorReason(userResult.Err); reason != expectedReasons[userResult.UserID] {
+			t.Errorf("Expected %q for %s, got %q", expectedReasons[userResult.UserID], userResult.UserID, reason)
+		}
+	}
+}
+
+func TestResolveOtherLocation(t *testing.T) {
+	userResolver, store := newTestResolver(t)
+	if err := store.SetLink("222", storage.Link{Location: ".co.uk", UserID: "7370"}); err != nil {
+		t.Fatalf("Failed to store link: %v", err)
+	}
+	if err := store.RememberManagers("https://onlineliga.de", []storage.Manager{{UserID: 5120, TeamName: "Test C"}}); err != nil {
+		t.Fatalf("Failed to store manager: %v", err)
+	}
+
+	// Links and names only resolve at their own location
+	userIDs, unresolved := userResolver.Resolve(".co.uk", []string{"<@222>", "Test C"})
+	if !slices.Equal(userIDs, []string{"7370"}) {
+		t.Errorf("Expected %v, got %v", []string{"7370"}, userIDs)
+	}
+	if len(unresolved) != 1 || scraper.ErrorReason(unresolved[0].Err) != "unknown name" {
+		t.Errorf("Expected Test C to be unknown, got %v", unresolved)
+	}
+}
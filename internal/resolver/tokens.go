@@ -0,0 +1,79 @@
+package resolver
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/storage"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxChoices is the maximum number of choices discord accepts for an autocomplete response
+	MaxChoices = 25
+	// MaxChoiceLength is the maximum length of the name and the value of a choice
+	MaxChoiceLength = 100
+)
+
+// SplitTokens splits the users option at whitespace, keeping double quoted names like "FC Test" together
+func SplitTokens(input string) []string {
+	var tokens []string
+	for i, part := range strings.Split(input, `"`) {
+		// Every odd part was enclosed in quotes
+		if i%2 == 1 {
+			if name := strings.TrimSpace(part); name != "" {
+				tokens = append(tokens, name)
+			}
+			continue
+		}
+		tokens = append(tokens, strings.Fields(part)...)
+	}
+	return tokens
+}
+
+// SplitLastToken splits the input of an option with several users into everything before the last token and the
+// last, partially typed token
+func SplitLastToken(input string) (string, string) {
+	lastSpace := strings.LastIndexAny(input, " \t")
+	return input[:lastSpace+1], input[lastSpace+1:]
+}
+
+// Choices returns an autocomplete choice per manager that appends its user id to prefix. Managers whose value
+// would be longer than MaxChoiceLength are left out, long names are shortened at the front.
+func Choices(prefix string, managers []storage.Manager) []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(managers))
+	for _, manager := range managers {
+		value := prefix + strconv.Itoa(manager.UserID)
+		if len([]rune(value)) > MaxChoiceLength {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  truncate(prefix+managerLabel(manager.Username, manager.TeamName, manager.UserID), MaxChoiceLength),
+			Value: value,
+		})
+	}
+	return choices
+}
+
+// managerLabel describes a manager by username, team name and user id
+func managerLabel(username string, teamName string, userID int) string {
+	switch {
+	case username != "" && teamName != "":
+		return fmt.Sprintf("%s - %s (%d)", username, teamName, userID)
+	case username != "":
+		return fmt.Sprintf("%s (%d)", username, userID)
+	case teamName != "":
+		return fmt.Sprintf("%s (%d)", teamName, userID)
+	default:
+		return strconv.Itoa(userID)
+	}
+}
+
+// truncate shortens text to at most limit characters, keeping the end which holds the user id
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return "…" + string(runes[len(runes)-limit+1:])
+}
@@ -0,0 +1,84 @@
+package resolver_test
+
+import (
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/resolver"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/storage"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitTokens(t *testing.T) {
+	tests := map[string][]string{
+		"":                         nil,
+		"8315 7370":                {"8315", "7370"},
+		"  8315\t7370\n":           {"8315", "7370"},
+		`"FC Test" 8315`:           {"FC Test", "8315"},
+		`8315 "FC Test"tester`:     {"8315", "FC Test", "tester"},
+		`<@123> tester "A  B " 42`: {"<@123>", "tester", "A  B", "42"},
+		`"" 8315 "  "`:             {"8315"},
+		`8315 "FC Te`:              {"8315", "FC Te"},
+		`<@!123>,8315`:             {"<@!123>,8315"},
+	}
+	for input, expected := range tests {
+		if tokens := resolver.SplitTokens(input); !slices.Equal(tokens, expected) {
+			t.Errorf("Expected %q for %q, got %q", expected, input, tokens)
+		}
+	}
+}
+
+func TestSplitLastToken(t *testing.T) {
+	tests := map[string][2]string{
+		"":               {"", ""},
+		"tes":            {"", "tes"},
+		"8315 tes":       {"8315 ", "tes"},
+		"8315 <@123> ":   {"8315 <@123> ", ""},
+		"8315\tTest Cit": {"8315\tTest ", "Cit"},
+	}
+	for input, expected := range tests {
+		if prefix, query := resolver.SplitLastToken(input); prefix != expected[0] || query != expected[1] {
+			t.Errorf("Expected %q for %q, got %q", expected, input, [2]string{prefix, query})
+		}
+	}
+}
+
+func TestChoices(t *testing.T) {
+	managers := []storage.Manager{
+		{UserID: 8315, Username: "tester", TeamName: "Test A"},
+		{UserID: 7370, TeamName: "Test B"},
+		{UserID: 5120, Username: strings.Repeat("ö", 150)},
+		{UserID: 1234},
+	}
+
+	choices := resolver.Choices("42 ", managers)
+	expected := [][2]string{
+		{"42 tester - Test A (8315)", "42 8315"},
+		{"42 Test B (7370)", "42 7370"},
+		{"…" + strings.Repeat("ö", 92) + " (5120)", "42 5120"},
+		{"42 1234", "42 1234"},
+	}
+	if len(choices) != len(expected) {
+		t.Fatalf("Expected %d choices, got %d", len(expected), len(choices))
+	}
+	for index, choice := range choices {
+		if choice.Name != expected[index][0] || choice.Value != expected[index][1] {
+			t.Errorf("Expected %q, got %q", expected[index], [2]any{choice.Name, choice.Value})
+		}
+		// Long names are cut at rune boundaries and keep the user id at the end
+		if length := utf8.RuneCountInString(choice.Name); length > resolver.MaxChoiceLength || !utf8.ValidString(choice.Name) {
+			t.Errorf("Expected a valid name of at most %d characters, got %d characters", resolver.MaxChoiceLength, length)
+		}
+	}
+
+	// Values that would exceed the limit cannot be completed and are left out
+	prefix := strings.Repeat("ü", resolver.MaxChoiceLength-4) + " "
+	choices = resolver.Choices(prefix, managers)
+	if len(choices) != 0 {
+		t.Errorf("Expected no choices, got %d", len(choices))
+	}
+	prefix = strings.Repeat("ü", resolver.MaxChoiceLength-5) + " "
+	if choices = resolver.Choices(prefix, managers); len(choices) != len(managers) {
+		t.Errorf("Expected %d choices, got %d", len(managers), len(choices))
+	}
+}
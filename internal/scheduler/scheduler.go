@@ -0,0 +1,154 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/scraper"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/storage"
+	"time"
+)
+
+// PostFunc publishes the results of a new matchday to a channel of a guild
+type PostFunc func(ctx context.Context, guildID string, channelID string, results []parse.MatchResult) error
+
+// BaseURLFunc maps a location like ".de" to the base url of the onlineliga instance
+type BaseURLFunc func(location string) string
+
+// Scheduler periodically polls the watchlists of all guilds with autopost enabled
+// and posts the results once a new matchday has been played
+type Scheduler struct {
+	store    *storage.Store
+	scraper  scraper.Scraper
+	logger   *logrus.Logger
+	interval time.Duration
+	baseURL  BaseURLFunc
+	post     PostFunc
+	afterAll func()
+}
+
+// Option configures optional behaviour of a Scheduler
+type Option func(*Scheduler)
+
+// WithAfterPollAll registers a function that is called once all guilds have been polled, e.g. to store
+// what the scraper collected during the poll
+func WithAfterPollAll(afterAll func()) Option {
+	return func(s *Scheduler) {
+		s.afterAll = afterAll
+	}
+}
+
+// NewScheduler returns a new Scheduler that polls every interval
+func NewScheduler(store *storage.Store, olScraper scraper.Scraper, logger *logrus.Logger, interval time.Duration, baseURL BaseURLFunc, post PostFunc, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		store:    store,
+		scraper:  olScraper,
+		logger:   logger,
+		interval: interval,
+		baseURL:  baseURL,
+		post:     post,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Run polls all guilds every interval until stop is closed or ctx is cancelled.
+// A poll in progress is only interrupted by ctx, closing stop lets it finish.
+func (s *Scheduler) Run(ctx context.Context, stop <-chan struct{}) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.PollAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PollAll polls every guild that has autopost enabled
+func (s *Scheduler) PollAll(ctx context.Context) {
+	if s.afterAll != nil {
+		defer s.afterAll()
+	}
+	configs, err := s.store.AutopostConfigs()
+	if err != nil {
+		s.logger.WithError(err).Error("Loading autopost configs failed")
+		return
+	}
+	for guildID, config := range configs {
+		if ctx.Err() != nil {
+			return
+		}
+		if pollErr := s.PollGuild(ctx, guildID, config); pollErr != nil {
+			s.logger.WithError(pollErr).WithField("guildID", guildID).Error("Autopost failed")
+		}
+	}
+}
+
+// PollGuild scrapes the watchlist of a guild and posts the results once a new matchday has been played, that is
+// the matchday differs from the last one and the last match of a watched user changed. A lower matchday than the
+// last one starts a new season. The first poll after autopost was enabled only records the current matchday, so
+// a matchday that is already over is not posted.
+func (s *Scheduler) PollGuild(ctx context.Context, guildID string, config storage.AutopostConfig) error {
+	watchlist, err := s.store.Watchlist(guildID)
+	if err != nil {
+		return err
+	}
+	if len(watchlist.UserIDs) == 0 {
+		return nil
+	}
+
+	userResults := s.scraper.ScrapeMatchResultsContext(ctx, watchlist.UserIDs, s.baseURL(watchlist.Location))
+	var results []parse.MatchResult
+	matchIDs := make(map[string]int, len(userResults))
+	matchday := 0
+	for _, userResult := range userResults {
+		if userResult.Err != nil {
+			s.logger.WithError(userResult.Err).WithField("guildID", guildID).
+				Warnf("Scraping user %s for autopost failed", userResult.UserID)
+			continue
+		}
+		results = append(results, userResult.Result)
+		matchIDs[userResult.UserID] = userResult.Result.MatchID
+		matchday = max(matchday, userResult.Result.Matchday)
+	}
+
+	if len(results) == 0 || matchday == config.LastMatchday || !hasNewMatches(config.LastMatchIDs, matchIDs) {
+		return nil
+	}
+	if config.LastMatchday == 0 {
+		s.logger.WithField("guildID", guildID).Infof("Autopost starts after matchday %d", matchday)
+		_, err = s.store.MarkAutopostSeen(guildID, matchIDs, matchday)
+		return err
+	}
+
+	if matchday < config.LastMatchday {
+		s.logger.WithField("guildID", guildID).Infof("New season detected after matchday %d", config.LastMatchday)
+	}
+	s.logger.WithField("guildID", guildID).Infof("New matchday %d detected, posting to channel %s", matchday, config.ChannelID)
+	if err = s.post(ctx, guildID, config.ChannelID, results); err != nil {
+		return err
+	}
+	_, err = s.store.MarkAutoposted(guildID, matchIDs, matchday, time.Now())
+	return err
+}
+
+// hasNewMatches reports whether the last match of any user differs from the last recorded one. Users without a
+// recorded match, e.g. ones added to the watchlist since, only count if no match has been recorded at all.
+func hasNewMatches(lastMatchIDs map[string]int, matchIDs map[string]int) bool {
+	if len(lastMatchIDs) == 0 {
+		return true
+	}
+	for userID, matchID := range matchIDs {
+		if lastMatchID, ok := lastMatchIDs[userID]; ok && lastMatchID != matchID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package scheduler_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/sirupsen/logrus"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/olfake"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/scheduler"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/scraper"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/storage"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const guildID = "guild"
+
+// overviewOnMatchday returns the overview of userID with its last match played on matchday of season
+func overviewOnMatchday(userID string, season int, matchday int) []byte {
+	overview := bytes.Replace(olfake.OverviewFor(userID), []byte(`"matchday": 2,`), []byte(`"matchday": `+strconv.Itoa(matchday)+`,`), 1)
+	matchID := strconv.Itoa(season*100 + matchday)
+	return bytes.Replace(overview, []byte(`"matchId": 1878,`), []byte(`"matchId": `+matchID+`,`), 1)
+}
+
+// newTestScheduler returns a scheduler for the fake server that records the matchdays it posts
+func newTestScheduler(t *testing.T, server *olfake.Server, userIDs []string) (*scheduler.Scheduler, *storage.Store, *[]int) {
+	store, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if _, err = store.AddToWatchlist(guildID, ".de", userIDs); err != nil {
+		t.Fatalf("Failed to fill watchlist: %v", err)
+	}
+	if _, err = store.SetAutopostChannel(guildID, "channel"); err != nil {
+		t.Fatalf("Failed to enable autopost: %v", err)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	olScraper := scraper.NewScraper(logger, scraper.WithHTTPClient(server.Client()))
+	var posted []int
+	post := func(ctx context.Context, guildID string, channelID string, results []parse.MatchResult) error {
+		posted = append(posted, results[0].Matchday)
+		return nil
+	}
+	baseURL := func(string) string { return server.URL() }
+	return scheduler.NewScheduler(store, olScraper, logger, time.Minute, baseURL, post), store, &posted
+}
+
+// poll polls the test guild once
+func poll(t *testing.T, autopostScheduler *scheduler.Scheduler, store *storage.Store) {
+	config, err := store.AutopostConfig(guildID)
+	if err != nil {
+		t.Fatalf("Failed to load autopost config: %v", err)
+	}
+	if err = autopostScheduler.PollGuild(context.Background(), guildID, config); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func TestPollGuildTransientFailure(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+	for _, userID := range []string{"1001", "1002"} {
+		server.SetOverview(userID, overviewOnMatchday(userID, 1, 2))
+	}
+	autopostScheduler, store, posted := newTestScheduler(t, server, []string{"1001", "1002"})
+
+	// The matchday that is over when autopost is enabled is not posted
+	poll(t, autopostScheduler, store)
+	if len(*posted) != 0 {
+		t.Fatalf("Expected no post, got %v", *posted)
+	}
+
+	// A user failing for one poll does not repost the matchday
+	server.Fail("1002", http.StatusInternalServerError)
+	poll(t, autopostScheduler, store)
+	server.Fail("1002", 0)
+	poll(t, autopostScheduler, store)
+	if len(*posted) != 0 {
+		t.Fatalf("Expected no post, got %v", *posted)
+	}
+
+	for _, userID := range []string{"1001", "1002"} {
+		server.SetOverview(userID, overviewOnMatchday(userID, 1, 3))
+	}
+	poll(t, autopostScheduler, store)
+	poll(t, autopostScheduler, store)
+	if len(*posted) != 1 || (*posted)[0] != 3 {
+		t.Errorf("Expected matchday 3 to be posted once, got %v", *posted)
+	}
+}
+
+func TestPollGuildNewUser(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+	for _, userID := range []string{"1001", "1002"} {
+		server.SetOverview(userID, overviewOnMatchday(userID, 1, 3))
+	}
+	autopostScheduler, store, posted := newTestScheduler(t, server, []string{"1001"})
+	poll(t, autopostScheduler, store)
+
+	// A user added to the watchlist after the matchday has been seen does not repost it
+	if _, err := store.AddToWatchlist(guildID, ".de", []string{"1002"}); err != nil {
+		t.Fatalf("Failed to fill watchlist: %v", err)
+	}
+	poll(t, autopostScheduler, store)
+	if len(*posted) != 0 {
+		t.Errorf("Expected no post, got %v", *posted)
+	}
+	config, err := store.AutopostConfig(guildID)
+	if err != nil {
+		t.Fatalf("Failed to load autopost config: %v", err)
+	}
+	if config.LastMatchday != 3 || len(config.LastMatchIDs) != 1 {
+		t.Errorf("Expected matchday 3 with 1 match id, got %d with %v", config.LastMatchday, config.LastMatchIDs)
+	}
+}
+
+func TestPollGuildNewSeason(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+	server.SetOverview("1001", overviewOnMatchday("1001", 1, 34))
+	autopostScheduler, store, posted := newTestScheduler(t, server, []string{"1001"})
+	poll(t, autopostScheduler, store)
+
+	// The matchday starts over with the new season
+	server.SetOverview("1001", overviewOnMatchday("1001", 2, 1))
+	poll(t, autopostScheduler, store)
+	poll(t, autopostScheduler, store)
+	server.SetOverview("1001", overviewOnMatchday("1001", 2, 2))
+	poll(t, autopostScheduler, store)
+	if len(*posted) != 2 || (*posted)[0] != 1 || (*posted)[1] != 2 {
+		t.Errorf("Expected matchdays 1 and 2 to be posted once, got %v", *posted)
+	}
+}
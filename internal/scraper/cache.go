@@ -0,0 +1,215 @@
+package scraper
+
+import (
+	"context"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"golang.org/x/sync/singleflight"
+	"sync"
+	"time"
+)
+
+// CacheConfig configures how long decoded overviews are kept
+type CacheConfig struct {
+	// TTL is how long an overview is used without asking onlineliga again
+	TTL time.Duration
+	// KickoffTTL replaces TTL for overviews whose next match kicks off within KickoffWindow, so results
+	// show up quickly after a matchday
+	KickoffTTL    time.Duration
+	KickoffWindow time.Duration
+	// MaxEntries limits the number of cached overviews, expired ones are dropped first
+	MaxEntries int
+}
+
+// DefaultCacheConfig is used unless the deployment configures otherwise
+var DefaultCacheConfig = CacheConfig{
+	TTL:           5 * time.Minute,
+	KickoffTTL:    30 * time.Second,
+	KickoffWindow: 2 * time.Hour,
+	MaxEntries:    5000,
+}
+
+// CacheStats counts how overview requests were answered
+type CacheStats struct {
+	// Hits were answered from the cache
+	Hits int64
+	// Misses were fetched from onlineliga
+	Misses int64
+	// Shared waited for a fetch of the same overview that was already in flight
+	Shared int64
+	// Revalidated were expired but confirmed by onlineliga with 304 Not Modified
+	Revalidated int64
+}
+
+// OverviewCache keeps decoded overviews per base URL and user id and deduplicates concurrent requests
+// for the same overview. It is safe for concurrent use and meant to be shared by all scrapers.
+type OverviewCache struct {
+	config CacheConfig
+	group  singleflight.Group
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	flights map[string]*flight
+	stats   CacheStats
+}
+
+// flight is a fetch in progress that is shared by all loads of the same overview. It is cancelled once the
+// last waiting load gives up.
+type flight struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+}
+
+// cacheEntry is a decoded overview with the validators of its response
+type cacheEntry struct {
+	root         parse.Root
+	etag         string
+	lastModified string
+	expires      time.Time
+}
+
+// NewCache returns an empty overview cache
+func NewCache(config CacheConfig) *OverviewCache {
+	return &OverviewCache{
+		config:  config,
+		now:     time.Now,
+		entries: map[string]*cacheEntry{},
+		flights: map[string]*flight{},
+	}
+}
+
+// WithCache answers overview requests from a shared cache
+func WithCache(cache *OverviewCache) Option {
+	return func(s *Scraper) {
+		s.cache = cache
+	}
+}
+
+// Stats returns how overview requests were answered so far
+func (c *OverviewCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// fetchFunc fetches an overview. stale is the expired entry of the overview, if there is one, so the request
+// can be made conditional. It returns stale itself if onlineliga answered 304 Not Modified.
+type fetchFunc func(ctx context.Context, stale *cacheEntry) (*cacheEntry, error)
+
+// load returns the overview of a user from the cache or fetches it. Concurrent loads of the same overview
+// share one fetch. Every load stops waiting once its own ctx is done, the shared fetch is cancelled when no
+// load waits for it anymore.
+func (c *OverviewCache) load(ctx context.Context, baseURL string, userID string, fetch fetchFunc) (parse.Root, error) {
+	key := baseURL + "|" + userID
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && c.now().Before(entry.expires) {
+		c.stats.Hits++
+		c.mu.Unlock()
+		return entry.root, nil
+	}
+	f, ok := c.flights[key]
+	if !ok {
+		// The fetch must not end with the ctx of the load that starts it while others still wait for it
+		fetchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{ctx: fetchCtx, cancel: cancel}
+		c.flights[key] = f
+	}
+	f.waiters++
+	c.mu.Unlock()
+	defer c.leave(key, f)
+
+	leader := false
+	results := c.group.DoChan(key, func() (any, error) {
+		leader = true
+		c.mu.Lock()
+		stale := c.entries[key]
+		c.mu.Unlock()
+
+		fetched, err := fetch(f.ctx, stale)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if fetched == stale {
+			c.stats.Revalidated++
+		} else {
+			c.stats.Misses++
+		}
+		fetched.expires = c.now().Add(c.ttl(fetched.root))
+		c.entries[key] = fetched
+		c.evict()
+		return fetched.root, nil
+	})
+
+	var result singleflight.Result
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		return parse.Root{}, ctx.Err()
+	}
+	if !leader {
+		c.mu.Lock()
+		c.stats.Shared++
+		c.mu.Unlock()
+	}
+	if result.Err != nil {
+		return parse.Root{}, result.Err
+	}
+	return result.Val.(parse.Root), nil
+}
+
+// leave stops waiting for a flight and cancels it if nobody else waits for it. A cancelled flight is forgotten,
+// so later loads of the same overview start a new fetch instead of sharing the cancelled one.
+func (c *OverviewCache) leave(key string, f *flight) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f.waiters--
+	if f.waiters > 0 {
+		return
+	}
+	f.cancel()
+	if c.flights[key] == f {
+		delete(c.flights, key)
+		c.group.Forget(key)
+	}
+}
+
+// ttl returns how long an overview may be cached, shorter if its next match kicks off soon or just did
+func (c *OverviewCache) ttl(root parse.Root) time.Duration {
+	kickoff := root.MatchData.NextMatch.Kickoff()
+	if !kickoff.IsZero() && c.config.KickoffTTL > 0 {
+		if untilKickoff := kickoff.Sub(c.now()); untilKickoff.Abs() <= c.config.KickoffWindow {
+			return min(c.config.KickoffTTL, c.config.TTL)
+		}
+	}
+	return c.config.TTL
+}
+
+// evict drops expired entries once the cache is full and, if that is not enough, the entries that expire first.
+// The caller has to hold c.mu.
+func (c *OverviewCache) evict() {
+	if c.config.MaxEntries <= 0 || len(c.entries) <= c.config.MaxEntries {
+		return
+	}
+	now := c.now()
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	for len(c.entries) > c.config.MaxEntries {
+		var oldestKey string
+		var oldest time.Time
+		for key, entry := range c.entries {
+			if oldestKey == "" || entry.expires.Before(oldest) {
+				oldestKey, oldest = key, entry.expires
+			}
+		}
+		delete(c.entries, oldestKey)
+	}
+}
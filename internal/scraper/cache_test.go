@@ -0,0 +1,162 @@
+package scraper_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/olfake"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/scraper"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOverviewCacheDeduplicates(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+	server.SetLatency(50 * time.Millisecond)
+
+	var hookCalls atomic.Int32
+	cache := scraper.NewCache(scraper.CacheConfig{TTL: time.Minute})
+	olScraper := newTestScraper(server, scraper.WithCache(cache), scraper.WithOverviewHook(func(string, parse.Root) {
+		hookCalls.Add(1)
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := olScraper.ScrapeMatchResult(context.Background(), olfake.DefaultUserID, server.URL()); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := olScraper.ScrapeMatchResult(context.Background(), olfake.DefaultUserID, server.URL()); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if server.OverviewRequests() != 1 || hookCalls.Load() != 1 {
+		t.Errorf("Expected 1 request and 1 hook call, got %d and %d", server.OverviewRequests(), hookCalls.Load())
+	}
+	expected := scraper.CacheStats{Hits: 1, Misses: 1, Shared: 9}
+	if stats := cache.Stats(); stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+}
+
+func TestOverviewCacheRevalidates(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+	server.SetETags(true)
+
+	cache := scraper.NewCache(scraper.CacheConfig{TTL: 20 * time.Millisecond})
+	olScraper := newTestScraper(server, scraper.WithCache(cache))
+	for i := 0; i < 2; i++ {
+		if _, err := olScraper.ScrapeMatchResult(context.Background(), olfake.DefaultUserID, server.URL()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		time.Sleep(30 * time.Millisecond)
+	}
+
+	if server.OverviewRequests() != 2 || server.NotModifiedResponses() != 1 {
+		t.Errorf("Expected 2 requests with 1 not modified, got %d with %d", server.OverviewRequests(), server.NotModifiedResponses())
+	}
+	expected := scraper.CacheStats{Misses: 1, Revalidated: 1}
+	if stats := cache.Stats(); stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+
+	// Errors are not cached
+	server.Fail(olfake.DefaultUserID, 500)
+	if _, err := olScraper.ScrapeMatchResult(context.Background(), olfake.DefaultUserID, server.URL()); err == nil {
+		t.Error("Expected an error")
+	}
+	server.Fail(olfake.DefaultUserID, 0)
+	if _, err := olScraper.ScrapeMatchResult(context.Background(), olfake.DefaultUserID, server.URL()); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestOverviewCacheKickoffTTL(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+	// The next match of 1001 kicks off now, the one of the default user long ago
+	kickoff := strconv.FormatInt(time.Now().Unix(), 10)
+	server.SetOverview("1001", bytes.ReplaceAll(olfake.OverviewFor("1001"), []byte("1732132800"), []byte(kickoff)))
+
+	cache := scraper.NewCache(scraper.CacheConfig{TTL: time.Hour, KickoffTTL: 20 * time.Millisecond, KickoffWindow: time.Hour})
+	olScraper := newTestScraper(server, scraper.WithCache(cache))
+	for i := 0; i < 2; i++ {
+		for _, userID := range []string{olfake.DefaultUserID, "1001"} {
+			if _, err := olScraper.ScrapeMatchResult(context.Background(), userID, server.URL()); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+	}
+
+	if server.OverviewRequests() != 3 {
+		t.Errorf("Expected %v, got %v", 3, server.OverviewRequests())
+	}
+}
+
+func TestOverviewCacheCancelsSharedFetch(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+	server.SetLatency(200 * time.Millisecond)
+	olScraper := newTestScraper(server, scraper.WithCache(scraper.NewCache(scraper.CacheConfig{TTL: time.Minute})))
+
+	// scrapeAll scrapes the default user once per timeout in parallel and returns the errors
+	scrapeAll := func(timeouts ...time.Duration) []error {
+		errs := make([]error, len(timeouts))
+		var wg sync.WaitGroup
+		for i, timeout := range timeouts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+				_, errs[i] = olScraper.ScrapeMatchResult(ctx, olfake.DefaultUserID, server.URL())
+			}()
+		}
+		wg.Wait()
+		return errs
+	}
+
+	// The fetch goes on as long as somebody waits for it
+	if errs := scrapeAll(20*time.Millisecond, time.Minute); errs[0] == nil || errs[1] != nil {
+		t.Errorf("Expected only the first scrape to time out, got %v", errs)
+	}
+	if server.AbortedRequests() != 0 {
+		t.Errorf("Expected %v, got %v", 0, server.AbortedRequests())
+	}
+
+	// Once all waiting scrapes gave up the request is aborted
+	server.SetOverview("1001", olfake.OverviewFor("1001"))
+	start := time.Now()
+	for _, timeout := range []time.Duration{20 * time.Millisecond, 40 * time.Millisecond} {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		go func() {
+			defer cancel()
+			_, _ = olScraper.ScrapeMatchResult(ctx, "1001", server.URL())
+		}()
+	}
+	for server.AbortedRequests() == 0 && time.Since(start) < time.Second {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if server.AbortedRequests() != 1 || server.OverviewRequests() != 2 {
+		t.Errorf("Expected 1 aborted of 2 requests, got %d of %d", server.AbortedRequests(), server.OverviewRequests())
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("Expected the request to be aborted before the response, took %v", elapsed)
+	}
+
+	// A later scrape starts a new fetch instead of sharing the cancelled one
+	if _, err := olScraper.ScrapeMatchResult(context.Background(), "1001", server.URL()); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
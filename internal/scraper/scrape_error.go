@@ -0,0 +1,174 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/httpclient"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// reasoner is implemented by errors that carry their own user-facing explanation
+type reasoner interface {
+	Reason() string
+}
+
+// scrapeError is the common part of the typed scraper errors. It wraps a parse.ResultError with the
+// message and, if there is one, the error that caused it.
+type scrapeError struct {
+	*parse.ResultError
+	UserID string
+	Err    error
+}
+
+// Unwrap returns the parse.ResultError and the cause so both can be found with errors.As
+func (e *scrapeError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.ResultError}
+	}
+	return []error{e.ResultError, e.Err}
+}
+
+func newScrapeError(userID string, err error, format string, args ...any) scrapeError {
+	return scrapeError{ResultError: &parse.ResultError{Msg: fmt.Sprintf(format, args...)}, UserID: userID, Err: err}
+}
+
+// NotFoundError is returned if onlineliga does not know a user or the user has no matching data, e.g. no last match
+type NotFoundError struct {
+	scrapeError
+}
+
+// Reason implements the user-facing explanation used by ErrorReason
+func (e *NotFoundError) Reason() string {
+	return "not found"
+}
+
+// RateLimitedError is returned if onlineliga answers with 429 Too Many Requests
+type RateLimitedError struct {
+	scrapeError
+	// RetryAfter is the wait time onlineliga asked for, zero if it did not send a Retry-After header
+	RetryAfter time.Duration
+}
+
+// Reason implements the user-facing explanation used by ErrorReason
+func (e *RateLimitedError) Reason() string {
+	return "rate limited, try again later"
+}
+
+// UpstreamUnavailableError is returned if onlineliga answers with a server error or another unexpected status
+// or cannot be reached at all
+type UpstreamUnavailableError struct {
+	scrapeError
+	// StatusCode is the status of the response, 0 if the request failed without one
+	StatusCode int
+}
+
+// Reason implements the user-facing explanation used by ErrorReason
+func (e *UpstreamUnavailableError) Reason() string {
+	return "onlineliga unavailable"
+}
+
+// InvalidUserIDError is returned for user ids that are not numeric, they are never sent to onlineliga
+type InvalidUserIDError struct {
+	scrapeError
+}
+
+// Reason implements the user-facing explanation used by ErrorReason
+func (e *InvalidUserIDError) Reason() string {
+	return "invalid ID"
+}
+
+// DecodeError is returned if the response of onlineliga could not be decoded
+type DecodeError struct {
+	scrapeError
+}
+
+// Reason implements the user-facing explanation used by ErrorReason
+func (e *DecodeError) Reason() string {
+	return "unreadable response"
+}
+
+// checkUserID returns an InvalidUserIDError if userID is not numeric
+func checkUserID(userID string) (int, error) {
+	userIDInt, err := strconv.Atoi(userID)
+	if err != nil {
+		return 0, &InvalidUserIDError{newScrapeError(userID, err, "Error: Invalid user id %q", userID)}
+	}
+	return userIDInt, nil
+}
+
+// checkResponse turns responses that are not 200 OK into typed errors
+func checkResponse(resp *http.Response, userID string) error {
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode == http.StatusNotFound:
+		return &NotFoundError{newScrapeError(userID, nil, "Error: User %s not found", userID)}
+	case resp.StatusCode == http.StatusTooManyRequests:
+		retryAfter, _ := httpclient.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		return &RateLimitedError{
+			scrapeError: newScrapeError(userID, nil, "Error: Rate limited while scraping user %s", userID),
+			RetryAfter:  retryAfter,
+		}
+	default:
+		return &UpstreamUnavailableError{
+			scrapeError: newScrapeError(userID, nil, "Error: Scraping user %s failed with status %s", userID, resp.Status),
+			StatusCode:  resp.StatusCode,
+		}
+	}
+}
+
+// transportError wraps an error of a request that got no complete response into an UpstreamUnavailableError.
+// Errors of a cancelled or expired ctx are returned as they are.
+func transportError(ctx context.Context, userID string, err error) error {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return &UpstreamUnavailableError{
+		scrapeError: newScrapeError(userID, err, "Error: Scraping user %s failed: %s", userID, err),
+	}
+}
+
+// decodeError wraps an error of parse.Overview into a DecodeError
+func decodeError(userID string, err error) error {
+	return &DecodeError{newScrapeError(userID, err, "Error: The overview of user %s could not be decoded: %s", userID, err)}
+}
+
+// notFoundError wraps a parse.ResultError of a decoded overview, e.g. a missing league table, into a NotFoundError.
+// Other errors are returned as they are.
+func notFoundError(userID string, err error) error {
+	var resultErr *parse.ResultError
+	if errors.As(err, &resultErr) {
+		return &NotFoundError{scrapeError{ResultError: resultErr, UserID: userID}}
+	}
+	return err
+}
+
+// ErrorReason returns a short, user-facing explanation of why scraping a user failed
+func ErrorReason(err error) string {
+	var errWithReason reasoner
+	var resultErr *parse.ResultError
+	var numErr *strconv.NumError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &errWithReason):
+		return errWithReason.Reason()
+	case errors.As(err, &resultErr):
+		return "not found"
+	case errors.As(err, &numErr):
+		return "invalid ID"
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return "unreadable response"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return "timed out"
+	default:
+		return "request failed"
+	}
+}
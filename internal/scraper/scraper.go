@@ -1,35 +1,104 @@
 package scraper
 
 import (
+	"context"
 	"github.com/sirupsen/logrus"
 	"github.com/tripleawwy/onlineliga_discord_bot/internal/httpclient"
 	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
 	"io"
 	"net/http"
-	"strconv"
 	"strings"
+	"sync"
 )
 
+// DefaultConcurrency is the number of users that are scraped in parallel if not configured otherwise
+const DefaultConcurrency = 10
+
 // Scraper is the interface for the scraper
 type Scraper struct {
-	client *http.Client
-	logger *logrus.Logger
-	url    string
+	client      *http.Client
+	logger      *logrus.Logger
+	url         string
+	concurrency int
+	onOverview  OverviewHook
+	onProgress  ProgressFunc
+	cache       *OverviewCache
+}
+
+// OverviewHook is called with every successfully decoded overview, e.g. to remember the managers it mentions
+type OverviewHook func(baseURL string, rootObject parse.Root)
+
+// Option configures optional behaviour of a Scraper
+type Option func(*Scraper)
+
+// WithConcurrency sets the maximum number of users that are scraped in parallel.
+// Values below 1 fall back to a sequential scrape.
+func WithConcurrency(concurrency int) Option {
+	return func(s *Scraper) {
+		if concurrency < 1 {
+			concurrency = 1
+		}
+		s.concurrency = concurrency
+	}
+}
+
+// WithHTTPClient sets the client that is used for all requests to onlineliga
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *Scraper) {
+		s.client = client
+	}
+}
+
+// WithOverviewHook registers a hook that is called with every successfully decoded overview
+func WithOverviewHook(hook OverviewHook) Option {
+	return func(s *Scraper) {
+		s.onOverview = hook
+	}
+}
+
+// ProgressFunc is called after each user of a multi user scrape has finished, successfully or not
+type ProgressFunc func(done int, total int)
+
+// WithProgress registers a callback that reports the progress of multi user scrapes.
+// The callback is never called concurrently.
+func WithProgress(progress ProgressFunc) Option {
+	return func(s *Scraper) {
+		s.onProgress = progress
+	}
 }
 
 // NewScraper returns a new Scraper
-func NewScraper(logger *logrus.Logger) Scraper {
-	return Scraper{
-		client: httpclient.DefaultHTTPClient,
-		logger: logger,
+func NewScraper(logger *logrus.Logger, opts ...Option) Scraper {
+	s := Scraper{
+		client:      httpclient.DefaultHTTPClient,
+		logger:      logger,
+		concurrency: DefaultConcurrency,
 	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// UserResult is the outcome of scraping a single user. Either Result or Err is set.
+type UserResult struct {
+	UserID string
+	Result parse.MatchResult
+	Err    error
+}
+
+// NextMatchResult is the outcome of scraping the upcoming match of a single user. Either NextMatch or Err is set.
+type NextMatchResult struct {
+	UserID    string
+	NextMatch parse.UpcomingMatch
+	Err       error
 }
 
 // ScrapeResults scrapes the results from onlineliga and takes user ids as input
-func (s *Scraper) ScrapeResults(userIDs []string, baseURL string) [][]string {
+func (s *Scraper) ScrapeResults(ctx context.Context, userIDs []string, baseURL string) [][]string {
 	var results [][]string
 	for _, userID := range userIDs {
-		result, scrapeErr := s.ScrapeResult(userID, baseURL)
+		result, scrapeErr := s.ScrapeResult(ctx, userID, baseURL)
 		if scrapeErr != nil {
 			s.logger.WithError(scrapeErr).Errorf("Scraping results for user %s failed... continuing with next", userID)
 			// Continue with the next user
@@ -42,46 +111,141 @@ func (s *Scraper) ScrapeResults(userIDs []string, baseURL string) [][]string {
 }
 
 // ScrapeResult scrapes the results from onlineliga and takes a user id as input
-func (s *Scraper) ScrapeResult(userID string, baseURL string) ([]string, error) {
-	overviewURL := strings.Join([]string{baseURL, "/apiv1/team/overview?userId=", userID}, "")
-	s.logger.WithField("userID", userID).Infof("URL is %s", overviewURL)
-	resp, err := s.client.Get(overviewURL)
+func (s *Scraper) ScrapeResult(ctx context.Context, userID string, baseURL string) ([]string, error) {
+	// Convert UserID to int
+	userIDInt, err := checkUserID(userID)
 	if err != nil {
 		return nil, err
 	}
-	defer func(Body io.ReadCloser) {
-		ReadCloserError := Body.Close()
-		if ReadCloserError != nil {
-			s.logger.WithError(ReadCloserError).
-				Warnf("Closing response body failed with error %s", ReadCloserError.Error())
-		}
-	}(resp.Body)
-
-	body, readErr := io.ReadAll(resp.Body)
-	if readErr != nil {
-		return nil, readErr
-	}
 
-	// Convert UserID to int
-	userIDInt, err := strconv.Atoi(userID)
+	_, body, err := s.requestOverview(ctx, userID, baseURL, nil)
 	if err != nil {
 		return nil, err
 	}
 	result, parseErr := parse.Result(body, userIDInt)
 	if parseErr != nil {
-		return nil, parseErr
+		return nil, notFoundError(userID, parseErr)
 	}
 
-	return result, err
+	return result, nil
 }
 
 // ScrapeMatchResult scrapes the match result from onlineliga, takes a match id as input and stores it in a MatchResult struct
-func (s *Scraper) ScrapeMatchResult(userID string, baseURL string) (parse.MatchResult, error) {
+func (s *Scraper) ScrapeMatchResult(ctx context.Context, userID string, baseURL string) (parse.MatchResult, error) {
+	// Convert UserID to int
+	userIDInt, err := checkUserID(userID)
+	if err != nil {
+		return parse.MatchResult{}, err
+	}
+
+	rootObject, err := s.ScrapeOverview(ctx, userID, baseURL)
+	if err != nil {
+		return parse.MatchResult{}, err
+	}
+
+	// Get the actual match result
+	result, err := parse.ResultFromRoot(rootObject, userIDInt)
+	return result, notFoundError(userID, err)
+}
+
+// ScrapeNextMatch scrapes the upcoming match of a user from onlineliga
+func (s *Scraper) ScrapeNextMatch(ctx context.Context, userID string, baseURL string) (parse.UpcomingMatch, error) {
+	// Convert UserID to int
+	userIDInt, err := checkUserID(userID)
+	if err != nil {
+		return parse.UpcomingMatch{}, err
+	}
+
+	rootObject, err := s.ScrapeOverview(ctx, userID, baseURL)
+	if err != nil {
+		return parse.UpcomingMatch{}, err
+	}
+
+	nextMatch, err := parse.NextMatchFromRoot(rootObject, userIDInt)
+	return nextMatch, notFoundError(userID, err)
+}
+
+// ScrapeNextMatchesContext scrapes the upcoming matches of all given users with a bounded number of workers.
+// The returned slice has the same order as userIDs and holds either the next match or the error for each user.
+func (s *Scraper) ScrapeNextMatchesContext(ctx context.Context, userIDs []string, baseURL string) []NextMatchResult {
+	nextMatches, errs := scrapeAll(ctx, s.concurrency, s.onProgress, userIDs, func(ctx context.Context, userID string) (parse.UpcomingMatch, error) {
+		return s.ScrapeNextMatch(ctx, userID, baseURL)
+	})
+
+	nextMatchResults := make([]NextMatchResult, len(userIDs))
+	for i, userID := range userIDs {
+		nextMatchResults[i] = NextMatchResult{UserID: userID, NextMatch: nextMatches[i], Err: errs[i]}
+	}
+	return nextMatchResults
+}
+
+// ScrapeOverview scrapes the team overview of a user from onlineliga and returns the decoded root object.
+// With a cache the overview is only requested again once it has expired.
+func (s *Scraper) ScrapeOverview(ctx context.Context, userID string, baseURL string) (parse.Root, error) {
+	// Reject invalid user ids before hitting onlineliga
+	if _, err := checkUserID(userID); err != nil {
+		return parse.Root{}, err
+	}
+
+	fetch := func(ctx context.Context, stale *cacheEntry) (*cacheEntry, error) {
+		return s.fetchOverview(ctx, userID, baseURL, stale)
+	}
+	if s.cache != nil {
+		return s.cache.load(ctx, baseURL, userID, fetch)
+	}
+	entry, err := fetch(ctx, nil)
+	if err != nil {
+		return parse.Root{}, err
+	}
+	return entry.root, nil
+}
+
+// fetchOverview requests and decodes the team overview of a user. With a stale cache entry the request is
+// conditional and the stale entry is returned if the overview has not been modified.
+func (s *Scraper) fetchOverview(ctx context.Context, userID string, baseURL string, stale *cacheEntry) (*cacheEntry, error) {
+	resp, body, err := s.requestOverview(ctx, userID, baseURL, stale)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		return stale, nil
+	}
+
+	rootObject, parseErr := parse.Overview(body)
+	if parseErr != nil {
+		return nil, decodeError(userID, parseErr)
+	}
+	if s.onOverview != nil {
+		s.onOverview(baseURL, rootObject)
+	}
+	return &cacheEntry{
+		root:         rootObject,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// requestOverview requests the team overview of a user and returns the response with the body of a
+// successful request. Error statuses are returned as typed errors.
+func (s *Scraper) requestOverview(ctx context.Context, userID string, baseURL string, stale *cacheEntry) (*http.Response, []byte, error) {
 	overviewURL := strings.Join([]string{baseURL, "/apiv1/team/overview?userId=", userID}, "")
 	s.logger.WithField("userID", userID).Infof("URL is %s", overviewURL)
-	resp, err := s.client.Get(overviewURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, overviewURL, nil)
 	if err != nil {
-		return parse.MatchResult{}, err
+		return nil, nil, err
+	}
+	if stale != nil {
+		if stale.etag != "" {
+			req.Header.Set("If-None-Match", stale.etag)
+		}
+		if stale.lastModified != "" {
+			req.Header.Set("If-Modified-Since", stale.lastModified)
+		}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, nil, transportError(ctx, userID, err)
 	}
 	defer func(Body io.ReadCloser) {
 		ReadCloserError := Body.Close()
@@ -91,38 +255,107 @@ func (s *Scraper) ScrapeMatchResult(userID string, baseURL string) (parse.MatchR
 		}
 	}(resp.Body)
 
-	body, readErr := io.ReadAll(resp.Body)
-	if readErr != nil {
-		return parse.MatchResult{}, readErr
+	if stale != nil && resp.StatusCode == http.StatusNotModified {
+		return resp, nil, nil
 	}
-
-	// Convert UserID to int
-	userIDInt, err := strconv.Atoi(userID)
-	if err != nil {
-		return parse.MatchResult{}, err
+	if statusErr := checkResponse(resp, userID); statusErr != nil {
+		// Drain the body so the connection can be reused
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, nil, statusErr
 	}
-
-	// Get the actual match result
-	result, parseErr := parse.ResultObject(body, userIDInt)
-	if parseErr != nil {
-		return parse.MatchResult{}, parseErr
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, transportError(ctx, userID, err)
 	}
-
-	return result, nil
+	return resp, body, nil
 }
 
-// ScrapeMatchResults scrapes the match results from onlineliga and takes user ids as input
-func (s *Scraper) ScrapeMatchResults(userIDs []string, baseURL string) []parse.MatchResult {
+// ScrapeMatchResults scrapes the match results from onlineliga and takes user ids as input.
+// Users that fail to scrape are logged and left out of the returned slice.
+func (s *Scraper) ScrapeMatchResults(ctx context.Context, userIDs []string, baseURL string) []parse.MatchResult {
 	var results []parse.MatchResult
-	for _, userID := range userIDs {
-		result, scrapeErr := s.ScrapeMatchResult(userID, baseURL)
-		if scrapeErr != nil {
-			s.logger.WithError(scrapeErr).Errorf("Scraping match results for user %s failed... continuing with next", userID)
-			// Continue with the next user
+	for _, userResult := range s.ScrapeMatchResultsContext(ctx, userIDs, baseURL) {
+		if userResult.Err != nil {
+			s.logger.WithError(userResult.Err).Errorf("Scraping match results for user %s failed... continuing with next", userResult.UserID)
 			continue
 		}
-		s.logger.WithField("userID", userID).Infof("Result for user %s is %s", userID, result)
-		results = append(results, result)
+		results = append(results, userResult.Result)
 	}
 	return results
 }
+
+// ScrapeMatchResultsContext scrapes the match results of all given users with a bounded number of workers.
+// The returned slice has the same order as userIDs and holds either the result or the error for each user.
+// Users that have not been scraped yet when ctx is cancelled get the context error.
+func (s *Scraper) ScrapeMatchResultsContext(ctx context.Context, userIDs []string, baseURL string) []UserResult {
+	results, errs := scrapeAll(ctx, s.concurrency, s.onProgress, userIDs, func(ctx context.Context, userID string) (parse.MatchResult, error) {
+		result, err := s.ScrapeMatchResult(ctx, userID, baseURL)
+		if err == nil {
+			s.logger.WithField("userID", userID).Infof("Result for user %s is %+v", userID, result)
+		}
+		return result, err
+	})
+
+	userResults := make([]UserResult, len(userIDs))
+	for i, userID := range userIDs {
+		userResults[i] = UserResult{UserID: userID, Result: results[i], Err: errs[i]}
+	}
+	return userResults
+}
+
+// scrapeAll calls scrape for every user id with at most concurrency parallel workers.
+// Results and errors are returned in the order of userIDs and progress, if set, is reported after each user.
+// Users that have not been scraped yet when ctx is cancelled get the context error, scrapes in flight are
+// cancelled through ctx.
+func scrapeAll[T any](ctx context.Context, concurrency int, progress ProgressFunc, userIDs []string, scrape func(ctx context.Context, userID string) (T, error)) ([]T, []error) {
+	results := make([]T, len(userIDs))
+	errs := make([]error, len(userIDs))
+
+	var progressMu sync.Mutex
+	done := 0
+	reportDone := func() {
+		if progress == nil {
+			return
+		}
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		done++
+		progress(done, len(userIDs))
+	}
+
+	workers := min(max(concurrency, 1), len(userIDs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					errs[i] = ctxErr
+					continue
+				}
+				results[i], errs[i] = scrape(ctx, userIDs[i])
+				reportDone()
+			}
+		}()
+	}
+
+	// Hand out the jobs until all users are scheduled or the context is cancelled
+	next := 0
+dispatch:
+	for ; next < len(userIDs); next++ {
+		select {
+		case jobs <- next:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i := next; i < len(userIDs); i++ {
+		errs[i] = ctx.Err()
+	}
+	return results, errs
+}
@@ -0,0 +1,230 @@
+package scraper_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/olfake"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/scraper"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestScraper(server *olfake.Server, opts ...scraper.Option) scraper.Scraper {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return scraper.NewScraper(logger, append([]scraper.Option{scraper.WithHTTPClient(server.Client())}, opts...)...)
+}
+
+func TestScrapeMatchResults(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+	server.SetOverview("1001", olfake.OverviewFor("1001"))
+	server.SetOverview("1002", olfake.OverviewFor("1002"))
+	server.Fail("1002", http.StatusInternalServerError)
+	server.SetOverview("1003", olfake.OverviewFor("1003"))
+	server.Fail("1003", http.StatusTooManyRequests)
+	server.SetOverview("1004", olfake.OverviewFor("1004"))
+	server.Malform("1004", true)
+
+	olScraper := newTestScraper(server)
+	results := olScraper.ScrapeMatchResults(context.Background(), []string{olfake.DefaultUserID, "404", "1002", "1003", "1004", "abc", "1001"}, server.URL())
+
+	expected := parse.MatchResult{
+		LeagueInfo:     parse.League{Level: 1},
+		LeagueLevel:    "OL1",
+		BadgeURL:       "https://bla.xyz/image/1.png",
+		LeaguePosition: "#11",
+		HomeTeam:       "Test A",
+		MatchResult:    "4 : 3",
+		MatchState:     "WIN",
+		AwayTeam:       "Test B",
+		Points:         "3 pts",
+		MatchID:        1878,
+		Matchday:       2,
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected %v, got %v", 2, len(results))
+	}
+	for _, result := range results {
+		if result != expected {
+			t.Errorf("Expected %v, got %v", expected, result)
+		}
+	}
+	// The invalid id never reaches the server
+	if server.OverviewRequests() != 6 {
+		t.Errorf("Expected %v, got %v", 6, server.OverviewRequests())
+	}
+}
+
+func TestScrapeMatchResultsContext(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+	server.SetOverview("1004", olfake.OverviewFor("1004"))
+	server.Malform("1004", true)
+
+	olScraper := newTestScraper(server)
+	userIDs := []string{"404", olfake.DefaultUserID, "1004", "abc"}
+	userResults := olScraper.ScrapeMatchResultsContext(context.Background(), userIDs, server.URL())
+
+	expectedReasons := []string{"not found", "", "unreadable response", "invalid ID"}
+	for i, userResult := range userResults {
+		if userResult.UserID != userIDs[i] {
+			t.Errorf("Expected %v, got %v", userIDs[i], userResult.UserID)
+		}
+		if expectedReasons[i] == "" {
+			if userResult.Err != nil {
+				t.Errorf("Expected no error for %s, got %v", userResult.UserID, userResult.Err)
+			}
+			continue
+		}
+		if reason := scraper.ErrorReason(userResult.Err); reason != expectedReasons[i] {
+			t.Errorf("Expected %v for %s, got %v", expectedReasons[i], userResult.UserID, reason)
+		}
+	}
+}
+
+func TestScrapeMatchResultErrors(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+	server.Fail("500", http.StatusInternalServerError)
+	server.Fail("503", http.StatusServiceUnavailable)
+	server.Fail("429", http.StatusTooManyRequests)
+	server.SetOverview("1004", olfake.OverviewFor("1004"))
+	server.Malform("1004", true)
+	// The overview of another user has no league table row for 1005
+	server.SetOverview("1005", olfake.DefaultOverview)
+
+	olScraper := newTestScraper(server)
+	scrape := func(userID string) error {
+		_, err := olScraper.ScrapeMatchResult(context.Background(), userID, server.URL())
+		return err
+	}
+
+	var notFoundErr *scraper.NotFoundError
+	for _, userID := range []string{"404", "1005"} {
+		if err := scrape(userID); !errors.As(err, &notFoundErr) {
+			t.Errorf("Expected a NotFoundError for %s, got %v", userID, err)
+		}
+	}
+
+	var rateLimitedErr *scraper.RateLimitedError
+	if err := scrape("429"); !errors.As(err, &rateLimitedErr) {
+		t.Errorf("Expected a RateLimitedError, got %v", err)
+	} else if rateLimitedErr.RetryAfter != time.Second {
+		t.Errorf("Expected %v, got %v", time.Second, rateLimitedErr.RetryAfter)
+	}
+
+	var unavailableErr *scraper.UpstreamUnavailableError
+	for _, status := range []int{http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		if err := scrape(strconv.Itoa(status)); !errors.As(err, &unavailableErr) {
+			t.Errorf("Expected an UpstreamUnavailableError for %d, got %v", status, err)
+		} else if unavailableErr.StatusCode != status {
+			t.Errorf("Expected %v, got %v", status, unavailableErr.StatusCode)
+		}
+	}
+
+	var invalidErr *scraper.InvalidUserIDError
+	if err := scrape("abc"); !errors.As(err, &invalidErr) {
+		t.Errorf("Expected an InvalidUserIDError, got %v", err)
+	}
+
+	var decodeErr *scraper.DecodeError
+	var syntaxErr *json.SyntaxError
+	err := scrape("1004")
+	if !errors.As(err, &decodeErr) || !errors.As(err, &syntaxErr) {
+		t.Errorf("Expected a DecodeError wrapping the JSON error, got %v", err)
+	}
+
+	// All typed errors wrap a parse.ResultError
+	var resultErr *parse.ResultError
+	for _, userID := range []string{"404", "429", "500", "abc", "1004"} {
+		if err := scrape(userID); !errors.As(err, &resultErr) {
+			t.Errorf("Expected a parse.ResultError for %s, got %v", userID, err)
+		}
+	}
+}
+
+func TestScrapeMatchResultUnreachable(t *testing.T) {
+	server := olfake.NewServer()
+	olScraper := newTestScraper(server)
+	baseURL := server.URL()
+	server.Close()
+
+	_, err := olScraper.ScrapeMatchResult(context.Background(), olfake.DefaultUserID, baseURL)
+	var unavailableErr *scraper.UpstreamUnavailableError
+	if !errors.As(err, &unavailableErr) {
+		t.Fatalf("Expected an UpstreamUnavailableError, got %v", err)
+	}
+	if unavailableErr.StatusCode != 0 {
+		t.Errorf("Expected %v, got %v", 0, unavailableErr.StatusCode)
+	}
+	if reason := scraper.ErrorReason(err); reason != "onlineliga unavailable" {
+		t.Errorf("Expected %q, got %q", "onlineliga unavailable", reason)
+	}
+
+	// Cancelled requests keep their context error
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = olScraper.ScrapeMatchResult(ctx, olfake.DefaultUserID, baseURL)
+	if !errors.Is(err, context.Canceled) || errors.As(err, &unavailableErr) {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestScrapeMatchResultsConcurrency(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+	server.SetLatency(100 * time.Millisecond)
+
+	userIDs := strings.Fields(strings.Repeat(olfake.DefaultUserID+" ", 10))
+	var progress []int
+	olScraper := newTestScraper(server, scraper.WithConcurrency(10), scraper.WithProgress(func(done int, total int) {
+		progress = append(progress, done)
+	}))
+
+	start := time.Now()
+	results := olScraper.ScrapeMatchResults(context.Background(), userIDs, server.URL())
+	elapsed := time.Since(start)
+
+	if len(results) != len(userIDs) {
+		t.Errorf("Expected %v, got %v", len(userIDs), len(results))
+	}
+	// Ten sequential requests would take a second
+	if elapsed > 600*time.Millisecond {
+		t.Errorf("Expected the users to be scraped in parallel, took %v", elapsed)
+	}
+	if len(progress) != len(userIDs) || progress[len(progress)-1] != len(userIDs) {
+		t.Errorf("Expected progress up to %v, got %v", len(userIDs), progress)
+	}
+}
+
+func TestScrapeMatchResultsCancel(t *testing.T) {
+	server := olfake.NewServer()
+	defer server.Close()
+	server.SetLatency(time.Second)
+
+	// Requests in flight are cancelled, with a cache every waiting scrape gives up on its own
+	userIDs := []string{olfake.DefaultUserID, olfake.DefaultUserID, "1001"}
+	for _, olScraper := range []scraper.Scraper{newTestScraper(server), newTestScraper(server, scraper.WithCache(scraper.NewCache(scraper.DefaultCacheConfig)))} {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		start := time.Now()
+		userResults := olScraper.ScrapeMatchResultsContext(ctx, userIDs, server.URL())
+		cancel()
+
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("Expected the scrape to end with the context, took %v", elapsed)
+		}
+		for _, userResult := range userResults {
+			if !errors.Is(userResult.Err, context.DeadlineExceeded) {
+				t.Errorf("Expected %v for %s, got %v", context.DeadlineExceeded, userResult.UserID, userResult.Err)
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"go.etcd.io/bbolt"
+	"time"
+)
+
+// AutopostConfig holds the automatic matchday posting settings of a guild
+type AutopostConfig struct {
+	ChannelID    string         `json:"channelId"`
+	Enabled      bool           `json:"enabled"`
+	LastMatchIDs map[string]int `json:"lastMatchIds"`
+	LastMatchday int            `json:"lastMatchday"`
+	LastPostedAt time.Time      `json:"lastPostedAt"`
+}
+
+// AutopostConfig returns the autopost settings of a guild
+func (s *Store) AutopostConfig(guildID string) (AutopostConfig, error) {
+	var config AutopostConfig
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		_, getErr := getJSON(tx, autopostBucket, guildID, &config)
+		return getErr
+	})
+	return config, err
+}
+
+// AutopostConfigs returns the autopost settings of all guilds that have autopost enabled, keyed by guild id
+func (s *Store) AutopostConfigs() (map[string]AutopostConfig, error) {
+	configs := make(map[string]AutopostConfig)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(autopostBucket).ForEach(func(key, _ []byte) error {
+			var config AutopostConfig
+			if _, getErr := getJSON(tx, autopostBucket, string(key), &config); getErr != nil {
+				return getErr
+			}
+			if config.Enabled {
+				configs[string(key)] = config
+			}
+			return nil
+		})
+	})
+	return configs, err
+}
+
+// SetAutopostChannel enables autopost for a guild and posts to the given channel. Enabling a disabled autopost
+// forgets the last posted matchday, so matchdays played in the meantime are not posted.
+func (s *Store) SetAutopostChannel(guildID string, channelID string) (AutopostConfig, error) {
+	return s.updateAutopost(guildID, func(config *AutopostConfig) {
+		if !config.Enabled {
+			config.LastMatchIDs = nil
+			config.LastMatchday = 0
+			config.LastPostedAt = time.Time{}
+		}
+		config.ChannelID = channelID
+		config.Enabled = true
+	})
+}
+
+// DisableAutopost disables autopost for a guild but keeps the remaining settings
+func (s *Store) DisableAutopost(guildID string) (AutopostConfig, error) {
+	return s.updateAutopost(guildID, func(config *AutopostConfig) {
+		config.Enabled = false
+	})
+}
+
+// MarkAutoposted records the matchday that has been posted for a guild. matchIDs are merged into the last
+// match ids, so users missing from one poll keep their last match.
+func (s *Store) MarkAutoposted(guildID string, matchIDs map[string]int, matchday int, postedAt time.Time) (AutopostConfig, error) {
+	return s.updateAutopost(guildID, func(config *AutopostConfig) {
+		config.mergeMatchIDs(matchIDs)
+		config.LastMatchday = matchday
+		config.LastPostedAt = postedAt
+	})
+}
+
+// MarkAutopostSeen records a matchday of a guild that is not posted, e.g. the one that was already over when
+// autopost was enabled
+func (s *Store) MarkAutopostSeen(guildID string, matchIDs map[string]int, matchday int) (AutopostConfig, error) {
+	return s.updateAutopost(guildID, func(config *AutopostConfig) {
+		config.mergeMatchIDs(matchIDs)
+		config.LastMatchday = matchday
+	})
+}
+
+// mergeMatchIDs adds or replaces the last match ids of the given users
+func (config *AutopostConfig) mergeMatchIDs(matchIDs map[string]int) {
+	if config.LastMatchIDs == nil {
+		config.LastMatchIDs = make(map[string]int, len(matchIDs))
+	}
+	for userID, matchID := range matchIDs {
+		config.LastMatchIDs[userID] = matchID
+	}
+}
+
+// updateAutopost applies update to the autopost settings of a guild within a single transaction
+func (s *Store) updateAutopost(guildID string, update func(config *AutopostConfig)) (AutopostConfig, error) {
+	var config AutopostConfig
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		if _, getErr := getJSON(tx, autopostBucket, guildID, &config); getErr != nil {
+			return getErr
+		}
+		update(&config)
+		return putJSON(tx, autopostBucket, guildID, config)
+	})
+	return config, err
+}
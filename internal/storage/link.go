@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"go.etcd.io/bbolt"
+)
+
+// Link maps a discord user to an onlineliga manager
+type Link struct {
+	Location    string `json:"location"`
+	UserID      string `json:"userId"`
+	CommunityID int    `json:"communityId"`
+	Username    string `json:"username"`
+}
+
+// Link returns the onlineliga manager linked to a discord user. It reports whether a link exists.
+func (s *Store) Link(discordUserID string) (Link, bool, error) {
+	var link Link
+	var found bool
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		var getErr error
+		found, getErr = getJSON(tx, linkBucket, discordUserID, &link)
+		return getErr
+	})
+	return link, found, err
+}
+
+// SetLink links a discord user to an onlineliga manager, replacing an existing link
+func (s *Store) SetLink(discordUserID string, link Link) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return putJSON(tx, linkBucket, discordUserID, link)
+	})
+}
+
+// DeleteLink removes the link of a discord user. It reports whether a link existed.
+func (s *Store) DeleteLink(discordUserID string) (bool, error) {
+	var found bool
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(linkBucket)
+		found = bucket.Get([]byte(discordUserID)) != nil
+		return bucket.Delete([]byte(discordUserID))
+	})
+	return found, err
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"go.etcd.io/bbolt"
+	"strconv"
+	"strings"
+)
+
+// Manager is an onlineliga manager that has been seen in a scraped overview
+type Manager struct {
+	UserID   int    `json:"userId"`
+	Username string `json:"username"`
+	TeamName string `json:"teamName"`
+}
+
+// managerPrefix returns the key prefix of all managers of an onlineliga instance
+func managerPrefix(baseURL string) []byte {
+	return []byte(baseURL + "|")
+}
+
+// managerKey returns the key of a manager of an onlineliga instance
+func managerKey(baseURL string, userID int) string {
+	return baseURL + "|" + strconv.Itoa(userID)
+}
+
+// RememberManagers stores the managers seen on an onlineliga instance. Known names are kept
+// if the new record lacks them, and the database is only written if something changed.
+func (s *Store) RememberManagers(baseURL string, managers []Manager) error {
+	var changed []Manager
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		for _, manager := range managers {
+			var known Manager
+			if _, getErr := getJSON(tx, managerBucket, managerKey(baseURL, manager.UserID), &known); getErr != nil {
+				return getErr
+			}
+			merged := mergeManager(known, manager)
+			if merged != known {
+				changed = append(changed, merged)
+			}
+		}
+		return nil
+	})
+	if err != nil || len(changed) == 0 {
+		return err
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		for _, manager := range changed {
+			var known Manager
+			if _, getErr := getJSON(tx, managerBucket, managerKey(baseURL, manager.UserID), &known); getErr != nil {
+				return getErr
+			}
+			if putErr := putJSON(tx, managerBucket, managerKey(baseURL, manager.UserID), mergeManager(known, manager)); putErr != nil {
+				return putErr
+			}
+		}
+		return nil
+	})
+}
+
+// mergeManager updates a known manager with the non-empty fields of a newly seen one
+func mergeManager(known Manager, seen Manager) Manager {
+	known.UserID = seen.UserID
+	if seen.Username != "" {
+		known.Username = seen.Username
+	}
+	if seen.TeamName != "" {
+		known.TeamName = seen.TeamName
+	}
+	return known
+}
+
+// FindManagers returns up to limit managers of an onlineliga instance whose user id, username or
+// team name contains the query, ignoring case. Managers whose names start with the query come first.
+func (s *Store) FindManagers(baseURL string, query string, limit int) ([]Manager, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	var prefixMatches, containsMatches []Manager
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		prefix := managerPrefix(baseURL)
+		cursor := tx.Bucket(managerBucket).Cursor()
+		for key, value := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, value = cursor.Next() {
+			var manager Manager
+			if err := json.Unmarshal(value, &manager); err != nil {
+				return err
+			}
+			username := strings.ToLower(manager.Username)
+			teamName := strings.ToLower(manager.TeamName)
+			userID := strconv.Itoa(manager.UserID)
+			switch {
+			case strings.HasPrefix(username, query), strings.HasPrefix(teamName, query), strings.HasPrefix(userID, query):
+				prefixMatches = append(prefixMatches, manager)
+			case strings.Contains(username, query), strings.Contains(teamName, query):
+				containsMatches = append(containsMatches, manager)
+			}
+		}
+		return nil
+	})
+	managers := append(prefixMatches, containsMatches...)
+	if len(managers) > limit {
+		managers = managers[:limit]
+	}
+	return managers, err
+}
+
+// ManagerByName returns the manager of an onlineliga instance whose username or team name equals name, ignoring case.
+// It reports whether such a manager is known.
+func (s *Store) ManagerByName(baseURL string, name string) (Manager, bool, error) {
+	var found Manager
+	var ok bool
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		prefix := managerPrefix(baseURL)
+		cursor := tx.Bucket(managerBucket).Cursor()
+		for key, value := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, value = cursor.Next() {
+			var manager Manager
+			if err := json.Unmarshal(value, &manager); err != nil {
+				return err
+			}
+			if strings.EqualFold(manager.Username, name) || strings.EqualFold(manager.TeamName, name) {
+				found, ok = manager, true
+				return nil
+			}
+		}
+		return nil
+	})
+	return found, ok, err
+}
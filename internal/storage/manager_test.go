@@ -0,0 +1,50 @@
+package storage_test
+
+import (
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/storage"
+	"path/filepath"
+	"testing"
+)
+
+func TestManagers(t *testing.T) {
+	store, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer store.Close()
+
+	baseURL := "https://www.onlineliga.de"
+	err = store.RememberManagers(baseURL, []storage.Manager{
+		{UserID: 8315, Username: "alice", TeamName: "Test A"},
+		{UserID: 7370, Username: "bob", TeamName: "FC Alpha"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to remember managers: %v", err)
+	}
+	// A league table entry without username must not erase the known username
+	if err = store.RememberManagers(baseURL, []storage.Manager{{UserID: 8315, TeamName: "Test A2"}}); err != nil {
+		t.Fatalf("Failed to remember managers: %v", err)
+	}
+	// Managers of other instances must not show up
+	if err = store.RememberManagers("https://www.onlineliga.at", []storage.Manager{{UserID: 1, Username: "alfred"}}); err != nil {
+		t.Fatalf("Failed to remember managers: %v", err)
+	}
+
+	manager, found, err := store.ManagerByName(baseURL, "ALICE")
+	if err != nil || !found {
+		t.Fatalf("Expected to find alice, got %v %v", found, err)
+	}
+	expectedManager := storage.Manager{UserID: 8315, Username: "alice", TeamName: "Test A2"}
+	if manager != expectedManager {
+		t.Errorf("Expected %v, got %v", expectedManager, manager)
+	}
+
+	// Prefix matches come before matches within a name
+	managers, err := store.FindManagers(baseURL, "al", 25)
+	if err != nil {
+		t.Fatalf("Failed to find managers: %v", err)
+	}
+	if len(managers) != 2 || managers[0].UserID != 8315 || managers[1].UserID != 7370 {
+		t.Errorf("Expected alice and bob, got %v", managers)
+	}
+}
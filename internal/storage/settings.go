@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"go.etcd.io/bbolt"
+)
+
+// GuildSettings holds the defaults a guild chose for its commands
+type GuildSettings struct {
+	Theme string `json:"theme"`
+}
+
+// GuildSettings returns the settings of a guild. A guild without settings gets empty ones.
+func (s *Store) GuildSettings(guildID string) (GuildSettings, error) {
+	var settings GuildSettings
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		_, getErr := getJSON(tx, settingsBucket, guildID, &settings)
+		return getErr
+	})
+	return settings, err
+}
+
+// SetGuildTheme stores the default image theme of a guild
+func (s *Store) SetGuildTheme(guildID string, theme string) (GuildSettings, error) {
+	var settings GuildSettings
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		if _, getErr := getJSON(tx, settingsBucket, guildID, &settings); getErr != nil {
+			return getErr
+		}
+		settings.Theme = theme
+		return putJSON(tx, settingsBucket, guildID, settings)
+	})
+	return settings, err
+}
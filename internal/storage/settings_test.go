@@ -0,0 +1,44 @@
+package storage_test
+
+import (
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/storage"
+	"path/filepath"
+	"testing"
+)
+
+func TestGuildTheme(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	store, err := storage.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+
+	if _, err = store.SetGuildTheme("42", "light"); err != nil {
+		t.Fatalf("Failed to set theme: %v", err)
+	}
+	if _, err = store.SetGuildTheme("43", "light"); err != nil {
+		t.Fatalf("Failed to set theme: %v", err)
+	}
+	settings, err := store.SetGuildTheme("43", "dark")
+	if err != nil || settings.Theme != "dark" {
+		t.Errorf("Expected dark, got %q (%v)", settings.Theme, err)
+	}
+
+	// The themes survive a restart and every guild keeps its own
+	if err = store.Close(); err != nil {
+		t.Fatalf("Failed to close store: %v", err)
+	}
+	store, err = storage.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+
+	expectedThemes := map[string]string{"42": "light", "43": "dark", "unknown": ""}
+	for guildID, expected := range expectedThemes {
+		settings, err = store.GuildSettings(guildID)
+		if err != nil || settings.Theme != expected {
+			t.Errorf("Expected %q for guild %s, got %q (%v)", expected, guildID, settings.Theme, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"encoding/json"
+	"go.etcd.io/bbolt"
+	"time"
+)
+
+var (
+	watchlistBucket = []byte("watchlists")
+	autopostBucket  = []byte("autopost")
+	linkBucket      = []byte("links")
+	managerBucket   = []byte("managers")
+	settingsBucket  = []byte("settings")
+)
+
+// Store persists guild specific settings in an embedded bbolt database
+type Store struct {
+	db *bbolt.DB
+}
+
+// Open opens (or creates) the database at the given path and makes sure all buckets exist
+func Open(path string) (*Store, error) {
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, bucket := range [][]byte{watchlistBucket, autopostBucket, linkBucket, managerBucket, settingsBucket} {
+			if _, bucketErr := tx.CreateBucketIfNotExists(bucket); bucketErr != nil {
+				return bucketErr
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the underlying database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// getJSON decodes the value stored under key into v. It reports whether the key exists.
+func getJSON(tx *bbolt.Tx, bucket []byte, key string, v any) (bool, error) {
+	data := tx.Bucket(bucket).Get([]byte(key))
+	if data == nil {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+// putJSON encodes v and stores it under key
+func putJSON(tx *bbolt.Tx, bucket []byte, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucket).Put([]byte(key), data)
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"fmt"
+	"go.etcd.io/bbolt"
+	"slices"
+)
+
+// Watchlist is the list of onlineliga users a guild follows
+type Watchlist struct {
+	Location string   `json:"location"`
+	UserIDs  []string `json:"userIds"`
+}
+
+// Watchlist returns the watchlist of a guild. A guild without a watchlist gets an empty one.
+func (s *Store) Watchlist(guildID string) (Watchlist, error) {
+	var watchlist Watchlist
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		_, getErr := getJSON(tx, watchlistBucket, guildID, &watchlist)
+		return getErr
+	})
+	return watchlist, err
+}
+
+// LocationMismatchError is returned if users of another location are added to a watchlist that is not empty.
+// Its ids belong to the saved location, so the watchlist has to be emptied before it changes.
+type LocationMismatchError struct {
+	Location string
+}
+
+func (e *LocationMismatchError) Error() string {
+	return fmt.Sprintf("the watchlist holds users of %s", e.Location)
+}
+
+// AddToWatchlist adds the user ids to the watchlist of a guild and returns the updated watchlist.
+// Ids that are already watched are skipped. An empty location keeps the saved one, another location than the
+// saved one is only taken by an empty watchlist and otherwise fails with a LocationMismatchError.
+func (s *Store) AddToWatchlist(guildID string, location string, userIDs []string) (Watchlist, error) {
+	var watchlist Watchlist
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		if _, getErr := getJSON(tx, watchlistBucket, guildID, &watchlist); getErr != nil {
+			return getErr
+		}
+		if location != "" && location != watchlist.Location {
+			if len(watchlist.UserIDs) > 0 && watchlist.Location != "" {
+				return &LocationMismatchError{Location: watchlist.Location}
+			}
+			watchlist.Location = location
+		}
+		for _, userID := range userIDs {
+			if !slices.Contains(watchlist.UserIDs, userID) {
+				watchlist.UserIDs = append(watchlist.UserIDs, userID)
+			}
+		}
+		return putJSON(tx, watchlistBucket, guildID, watchlist)
+	})
+	return watchlist, err
+}
+
+// RemoveFromWatchlist removes the user ids from the watchlist of a guild and returns the updated watchlist
+func (s *Store) RemoveFromWatchlist(guildID string, userIDs []string) (Watchlist, error) {
+	var watchlist Watchlist
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		if _, getErr := getJSON(tx, watchlistBucket, guildID, &watchlist); getErr != nil {
+			return getErr
+		}
+		watchlist.UserIDs = slices.DeleteFunc(watchlist.UserIDs, func(userID string) bool {
+			return slices.Contains(userIDs, userID)
+		})
+		return putJSON(tx, watchlistBucket, guildID, watchlist)
+	})
+	return watchlist, err
+}
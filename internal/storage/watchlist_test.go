@@ -0,0 +1,76 @@
+package storage_test
+
+import (
+	"errors"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/storage"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestWatchlist(t *testing.T) {
+	store, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer store.Close()
+
+	guildID := "42"
+	if _, err = store.AddToWatchlist(guildID, ".co.uk", []string{"8315", "7370"}); err != nil {
+		t.Fatalf("Failed to add to watchlist: %v", err)
+	}
+	// Adding a user twice and omitting the location must neither duplicate the user nor reset the location
+	if _, err = store.AddToWatchlist(guildID, "", []string{"7370", "1234"}); err != nil {
+		t.Fatalf("Failed to add to watchlist: %v", err)
+	}
+	if _, err = store.RemoveFromWatchlist(guildID, []string{"8315"}); err != nil {
+		t.Fatalf("Failed to remove from watchlist: %v", err)
+	}
+
+	watchlist, err := store.Watchlist(guildID)
+	if err != nil {
+		t.Fatalf("Failed to load watchlist: %v", err)
+	}
+	expectedUserIDs := []string{"7370", "1234"}
+	if watchlist.Location != ".co.uk" || !slices.Equal(watchlist.UserIDs, expectedUserIDs) {
+		t.Errorf("Expected .co.uk %v, got %s %v", expectedUserIDs, watchlist.Location, watchlist.UserIDs)
+	}
+
+	empty, err := store.Watchlist("unknown")
+	if err != nil || len(empty.UserIDs) != 0 {
+		t.Errorf("Expected empty watchlist for unknown guild, got %v (%v)", empty, err)
+	}
+}
+
+func TestWatchlistLocation(t *testing.T) {
+	store, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer store.Close()
+
+	guildID := "42"
+	if _, err = store.AddToWatchlist(guildID, ".co.uk", []string{"8315"}); err != nil {
+		t.Fatalf("Failed to add to watchlist: %v", err)
+	}
+
+	// The ids of a watchlist belong to its location, so it must not change while users are watched
+	var mismatchErr *storage.LocationMismatchError
+	_, err = store.AddToWatchlist(guildID, ".de", []string{"7370"})
+	if !errors.As(err, &mismatchErr) || mismatchErr.Location != ".co.uk" {
+		t.Errorf("Expected a LocationMismatchError for .co.uk, got %v", err)
+	}
+	watchlist, err := store.Watchlist(guildID)
+	if err != nil || watchlist.Location != ".co.uk" || !slices.Equal(watchlist.UserIDs, []string{"8315"}) {
+		t.Errorf("Expected the watchlist to be unchanged, got %v (%v)", watchlist, err)
+	}
+
+	// An emptied watchlist takes the new location
+	if _, err = store.RemoveFromWatchlist(guildID, []string{"8315"}); err != nil {
+		t.Fatalf("Failed to remove from watchlist: %v", err)
+	}
+	watchlist, err = store.AddToWatchlist(guildID, ".de", []string{"7370"})
+	if err != nil || watchlist.Location != ".de" || !slices.Equal(watchlist.UserIDs, []string{"7370"}) {
+		t.Errorf("Expected .de [7370], got %v (%v)", watchlist, err)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/scraper"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/storage"
+)
+
+// handleLink links the calling discord user to an onlineliga manager after checking that the manager exists
+func handleLink(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	olScraper, rememberManagers := newScraper()
+	defer rememberManagers()
+
+	options := optionMap(i.ApplicationCommandData().Options)
+	location := stringOption(options, "location")
+	userID := stringOption(options, "user")
+
+	// Defer the response, onlineliga may take longer than the 3 seconds discord waits for an answer
+	if err := deferResponse(s, i); err != nil {
+		logger.WithError(err).Error("Error deferring the interaction response")
+		return
+	}
+
+	rootObject, err := olScraper.ScrapeOverview(ctx, userID, getBaseURL(location))
+	if err != nil {
+		logger.WithError(err).Errorf("Scraping the overview of user %s for /link failed", userID)
+		_ = editResponse(s, i, fmt.Sprintf("Could not link %s (%s)", userID, scraper.ErrorReason(err)), nil)
+		return
+	}
+
+	link := storage.Link{
+		Location:    location,
+		UserID:      userID,
+		CommunityID: rootObject.User.League.CommunityId,
+		Username:    rootObject.User.Username,
+	}
+	if err = store.SetLink(interactionUserID(i), link); err != nil {
+		logger.WithError(err).Errorf("Storing the link to user %s failed", userID)
+		_ = editResponse(s, i, "Something went wrong while storing the link", nil)
+		return
+	}
+
+	name := link.Username
+	if name == "" {
+		name = userID
+	}
+	_ = editResponse(s, i, fmt.Sprintf("Linked you to %s (%s)", name, location), nil)
+}
+
+// handleUnlink removes the link of the calling discord user
+func handleUnlink(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	found, err := store.DeleteLink(interactionUserID(i))
+	if err != nil {
+		logger.WithError(err).Error("Deleting a link failed")
+		respondText(s, i, "Something went wrong while removing the link")
+		return
+	}
+	if !found {
+		respondText(s, i, "You are not linked to an onlineliga manager")
+		return
+	}
+	respondText(s, i, "Removed the link to your onlineliga manager")
+}
+
+// interactionUserID returns the discord user id of whoever triggered the interaction, in guilds and in DMs
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
@@ -1,20 +1,49 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"github.com/bwmarrin/discordgo"
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
-	"github.com/tripleawwy/onlineliga_discord_bot/internal/formatutils"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/badges"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/fonts"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/httpclient"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/lifecycle"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/registry"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/scheduler"
 	"github.com/tripleawwy/onlineliga_discord_bot/internal/scraper"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/storage"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
+	_ "time/tzdata"
 )
 
 // Set up global logger
 var logger = logrus.New()
 
+// store persists guild settings like watchlists
+var store *storage.Store
+
+// fontChain draws the text of all rendered images
+var fontChain = fonts.Default()
+
+// badgeCache keeps downloaded badges for all rendered images
+var badgeCache *badges.Cache
+
+// overviewCache keeps the team overviews of all scrapers for a short time
+var overviewCache *scraper.OverviewCache
+
+// scrapeConcurrency is the maximum number of users that are scraped in parallel per interaction
+var scrapeConcurrency = scraper.DefaultConcurrency
+
 func main() {
 	logger.Level = logrus.InfoLevel
 	logger.Formatter = &logrus.TextFormatter{
@@ -29,14 +58,157 @@ func main() {
 	}
 	token := os.Getenv("DISCO_BOT_TOKEN")
 
+	// Read the optional scrape concurrency
+	if concurrency := os.Getenv("SCRAPE_CONCURRENCY"); concurrency != "" {
+		scrapeConcurrency, err = strconv.Atoi(concurrency)
+		if err != nil || scrapeConcurrency < 1 {
+			logger.WithError(err).Fatalf("Invalid SCRAPE_CONCURRENCY %q", concurrency)
+		}
+	}
+
+	// Read the optional retry settings of requests to onlineliga and badge downloads
+	retryConfig := httpclient.DefaultRetryConfig
+	if maxRetries := os.Getenv("HTTP_MAX_RETRIES"); maxRetries != "" {
+		retryConfig.MaxRetries, err = strconv.Atoi(maxRetries)
+		if err != nil || retryConfig.MaxRetries < 0 {
+			logger.WithError(err).Fatalf("Invalid HTTP_MAX_RETRIES %q", maxRetries)
+		}
+	}
+	for name, delay := range map[string]*time.Duration{
+		"HTTP_RETRY_BASE_DELAY": &retryConfig.BaseDelay,
+		"HTTP_RETRY_MAX_DELAY":  &retryConfig.MaxDelay,
+		"HTTP_MAX_RETRY_AFTER":  &retryConfig.MaxRetryAfter,
+	} {
+		if value := os.Getenv(name); value != "" {
+			*delay, err = time.ParseDuration(value)
+			if err != nil || *delay < 0 {
+				logger.WithError(err).Fatalf("Invalid %s %q", name, value)
+			}
+		}
+	}
+	httpclient.DefaultRetryTransport.Config = retryConfig
+	httpclient.DefaultRetryTransport.OnRetry = func(req *http.Request, attempt int, wait time.Duration, reason string) {
+		logger.Warnf("Retrying %s %s in %s after attempt %d failed: %s", req.Method, req.URL, wait, attempt, reason)
+	}
+
+	// Read the optional request rates per host. RATE_LIMIT_DEFAULT applies to all hosts and RATE_LIMITS is a
+	// comma separated list of location=rate[:burst] like ".de=5:10,.at=2" for the onlineliga hosts.
+	if defaultLimit := os.Getenv("RATE_LIMIT_DEFAULT"); defaultLimit != "" {
+		limit, limitErr := httpclient.ParseHostLimit(defaultLimit)
+		if limitErr != nil {
+			logger.WithError(limitErr).Fatalf("Invalid RATE_LIMIT_DEFAULT %q", defaultLimit)
+		}
+		httpclient.DefaultHostLimiter.SetDefaultLimit(limit)
+	}
+	if rateLimits := os.Getenv("RATE_LIMITS"); rateLimits != "" {
+		for _, entry := range strings.Split(rateLimits, ",") {
+			location, value, _ := strings.Cut(strings.TrimSpace(entry), "=")
+			limit, limitErr := httpclient.ParseHostLimit(value)
+			baseURL, urlErr := url.Parse(getBaseURL(location))
+			if limitErr != nil || urlErr != nil || !isLocation(location) {
+				logger.WithError(errors.Join(limitErr, urlErr)).Fatalf("Invalid RATE_LIMITS entry %q", entry)
+			}
+			httpclient.DefaultHostLimiter.SetLimit(baseURL.Host, limit)
+		}
+	}
+
+	// Read the optional cache times of team overviews, a TTL of 0 only deduplicates concurrent requests
+	cacheConfig := scraper.DefaultCacheConfig
+	for name, ttl := range map[string]*time.Duration{
+		"OVERVIEW_CACHE_TTL":         &cacheConfig.TTL,
+		"OVERVIEW_CACHE_KICKOFF_TTL": &cacheConfig.KickoffTTL,
+	} {
+		if value := os.Getenv(name); value != "" {
+			*ttl, err = time.ParseDuration(value)
+			if err != nil || *ttl < 0 {
+				logger.WithError(err).Fatalf("Invalid %s %q", name, value)
+			}
+		}
+	}
+	overviewCache = scraper.NewCache(cacheConfig)
+
+	// Load the fonts once, FONT_PATH is the primary font and FONT_FALLBACK_PATHS a comma separated list of fonts
+	// for glyphs it lacks. The embedded default font backs up all of them.
+	fontPaths := []string{os.Getenv("FONT_PATH")}
+	if fallbackPaths := os.Getenv("FONT_FALLBACK_PATHS"); fallbackPaths != "" {
+		fontPaths = append(fontPaths, strings.Split(fallbackPaths, ",")...)
+	}
+	fontChain, err = fonts.Load(fontPaths...)
+	if err != nil {
+		logger.WithError(err).Warn("Some fonts could not be loaded, continuing with the remaining ones")
+	}
+
+	// Keep badges in memory and, if configured, on disk
+	badgeCache = badges.NewCache(httpclient.DefaultHTTPClient, 512, os.Getenv("BADGE_CACHE_DIR"))
+
+	// Run headless subcommands like results without connecting to discord
+	if len(os.Args) > 1 {
+		os.Exit(runCLI(os.Args[1:]))
+	}
+
+	// Read the optional autopost poll interval
+	autopostInterval := 10 * time.Minute
+	if interval := os.Getenv("AUTOPOST_INTERVAL"); interval != "" {
+		autopostInterval, err = time.ParseDuration(interval)
+		if err != nil || autopostInterval <= 0 {
+			logger.WithError(err).Fatalf("Invalid AUTOPOST_INTERVAL %q", interval)
+		}
+	}
+
+	// Read the optional time running interactions and autopost polls get to finish on shutdown
+	shutdownTimeout := 20 * time.Second
+	if timeout := os.Getenv("SHUTDOWN_TIMEOUT"); timeout != "" {
+		shutdownTimeout, err = time.ParseDuration(timeout)
+		if err != nil || shutdownTimeout < 0 {
+			logger.WithError(err).Fatalf("Invalid SHUTDOWN_TIMEOUT %q", timeout)
+		}
+	}
+
 	// Create a new Discord session using the provided bot token.
 	discord, err := discordgo.New("Bot " + string(token))
 	if err != nil {
 		logger.WithError(err).Fatal("Error creating Discord session")
 	}
 
+	// Open the storage for guild settings like watchlists
+	dbPath := os.Getenv("DB_PATH")
+	if dbPath == "" {
+		dbPath = "ol_discord_bot.db"
+	}
+	store, err = storage.Open(dbPath)
+	if err != nil {
+		logger.WithError(err).Fatalf("Error opening storage at %s", dbPath)
+	}
+
+	// Track interactions and autopost polls so they can finish on shutdown
+	manager := lifecycle.NewManager(logger)
+
 	// Register messageCreate as a callback for the messageCreate events.
-	discord.AddHandler(onInteractionCreate)
+	discord.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		release, ok := manager.Acquire()
+		if !ok {
+			if i.Type == discordgo.InteractionApplicationCommand {
+				respondText(s, i, "The bot is restarting, please try again in a moment")
+			}
+			return
+		}
+		defer release()
+		onInteractionCreate(manager.Context(), s, i)
+	})
+
+	// Register the commands for every guild once it is available, including guilds the bot joins later.
+	// With COMMANDS_GLOBAL=true they are published globally instead, which takes discord up to an hour to roll out.
+	globalCommands := false
+	if global := os.Getenv("COMMANDS_GLOBAL"); global != "" {
+		globalCommands, err = strconv.ParseBool(global)
+		if err != nil {
+			logger.WithError(err).Fatalf("Invalid COMMANDS_GLOBAL %q", global)
+		}
+	}
+	commandRegistry := registry.NewRegistry(discord, logger, commands, globalCommands)
+	discord.AddHandler(commandRegistry.OnReady)
+	discord.AddHandler(commandRegistry.OnGuildCreate)
+	discord.AddHandler(commandRegistry.OnGuildDelete)
 
 	// Open a websocket connection to Discord and begin listening.
 	err = discord.Open()
@@ -44,127 +216,119 @@ func main() {
 		logger.WithError(err).Fatal("Error opening connection")
 	}
 
-	commands := []*discordgo.ApplicationCommand{
-		{
-			Name:        "results",
-			Description: "Get the results of a user",
-			Options: []*discordgo.ApplicationCommandOption{
-				{
-					Type: discordgo.ApplicationCommandOptionString,
-					Name: "location",
-					Choices: []*discordgo.ApplicationCommandOptionChoice{
-						{
-							Name:  ".de",
-							Value: ".de",
-						},
-						{
-							Name:  ".co.uk",
-							Value: ".co.uk",
-						},
-						{
-							Name:  ".at",
-							Value: ".at",
-						},
-						{
-							Name:  ".ch",
-							Value: ".ch",
-						},
-					},
-					Description: "The location of the results",
-					Required:    true,
-				},
-				{
-					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "users",
-					Description: "The user ids to get the results for",
-					Required:    true,
-				},
-			},
-		},
-	}
-
-	// Bulk register the commands for all guilds
-	for _, guild := range discord.State.Guilds {
-		_, err = discord.ApplicationCommandBulkOverwrite(discord.State.User.ID, guild.ID, commands)
-		if err != nil {
-			logger.WithError(err).Errorf("Error registering commands for guild %s", guild.Name)
-		}
-	}
+	// Start posting the results of new matchdays automatically
+	autopostScraper, rememberManagers := newScraper()
+	autopostScheduler := scheduler.NewScheduler(
+		store,
+		autopostScraper,
+		logger,
+		autopostInterval,
+		getBaseURL,
+		postMatchday(discord),
+		scheduler.WithAfterPollAll(rememberManagers),
+	)
+	manager.Go(func(ctx context.Context) {
+		autopostScheduler.Run(ctx, manager.Stopping())
+	})
+	go logRequestStats(manager.Stopping(), requestStatsInterval)
 
 	// Wait here until interrupted.
 	logger.Infoln("Bot is now running. Press CTRL-C to exit.")
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, os.Kill)
 	<-sc
+	go func() {
+		<-sc
+		logger.Fatal("Interrupted again, exiting without waiting for running interactions")
+	}()
 
-	// Clean up resources.
-	err = discord.Close()
-	if err != nil {
-		logger.WithError(err).Fatal("Error closing connection")
+	// Let running interactions and polls finish, then clean up resources. The session is closed last so
+	// interactions that are still answered can reach discord.
+	logger.Infof("Shutting down, waiting up to %s for running interactions", shutdownTimeout)
+	manager.OnShutdown("logging request stats", func() error {
+		logStats()
+		return nil
+	})
+	manager.OnShutdown("closing storage", store.Close)
+	manager.OnShutdown("closing connection", discord.Close)
+	if err = manager.Shutdown(shutdownTimeout); err != nil {
+		logger.WithError(err).Fatal("Error shutting down")
 	}
+	logger.Infoln("Shutdown complete")
 }
 
-func onInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	olScraper := scraper.NewScraper(logger)
-
-	if i.ApplicationCommandData().Name == "results" {
-		location := i.ApplicationCommandData().Options[0].StringValue()
-		logger.Infof("Location: %s", location)
-		userIDs := strings.Split(i.ApplicationCommandData().Options[1].StringValue(), " ")
-
-		var content string
-		content = "```/results location: " + i.ApplicationCommandData().Options[0].StringValue() + " users: " + i.ApplicationCommandData().Options[1].StringValue() + "```"
-
-		// Send initial response
-		interactionErr := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "content",
-			},
-		})
-		if interactionErr != nil {
-			logger.WithError(interactionErr).Error("Error responding initially to interaction")
-		}
+// interactionTimeout bounds the work of an interaction. Discord accepts follow-ups for 15 minutes, the
+// remaining minute is left for the final response.
+const interactionTimeout = 14 * time.Minute
 
-		baseURL := getBaseURL(location)
-
-		results := olScraper.ScrapeMatchResults(userIDs, baseURL)
-		results = formatutils.SortResults(results, logger)
-		imageBuf, imageErr := formatutils.MatchResultsToImage(results, formatutils.ImageConfig{
-			FontSize:    14.0,
-			Margin:      28.0,
-			ColWidth:    150.0,
-			RowHeight:   28.0,
-			BadeWidth:   22.0,
-			BadgeHeight: 22.0,
-			R:           1.0,
-			G:           1.0,
-			B:           1.0,
-		})
-		if imageErr != nil {
-			logger.WithError(imageErr).Error("Error creating image")
-		}
+// onInteractionCreate dispatches an interaction to its handler. The handler's context ends with ctx or
+// after interactionTimeout.
+func onInteractionCreate(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		handleAutocomplete(s, i)
+		return
+	default:
+		return
+	}
 
-		// Read the image buffer into a reader
-		resultAsImage := strings.NewReader(string(imageBuf.Bytes()))
+	ctx, cancel := context.WithTimeout(ctx, interactionTimeout)
+	defer cancel()
 
-		filePayload := discordgo.File{
-			Name:   "SPOILER_results.png",
-			Reader: resultAsImage,
-		}
+	switch i.ApplicationCommandData().Name {
+	case "results":
+		handleResults(ctx, s, i)
+	case "watch":
+		handleWatch(s, i)
+	case "autopost":
+		handleAutopost(s, i)
+	case "table":
+		handleTable(ctx, s, i)
+	case "nextmatch":
+		handleNextMatch(ctx, s, i)
+	case "link":
+		handleLink(ctx, s, i)
+	case "unlink":
+		handleUnlink(s, i)
+	case "theme":
+		handleTheme(s, i)
+	}
+}
+
+// isLocation reports whether location is one of the onlineliga instances of locationChoices
+func isLocation(location string) bool {
+	return slices.ContainsFunc(locationChoices, func(choice *discordgo.ApplicationCommandOptionChoice) bool {
+		return choice.Value == location
+	})
+}
 
-		_, interactionEditErr := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-			Content: &content,
-			Files: []*discordgo.File{
-				&filePayload,
-			},
-		})
+// requestStatsInterval is how often the queueing metrics of the rate limiter and the cache stats are logged
+const requestStatsInterval = 15 * time.Minute
 
-		if interactionEditErr != nil {
-			logger.WithError(interactionEditErr).Error("Error responding to interaction")
+// logRequestStats logs the request stats every interval until stop is closed
+func logRequestStats(stop <-chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			logStats()
 		}
 	}
-	return
+}
+
+// logStats logs the queueing metrics of the rate limiter per host and the overview cache stats
+func logStats() {
+	for host, stats := range httpclient.DefaultHostLimiter.Stats() {
+		logger.WithField("host", host).Infof("Requests: %d, delayed: %d, waiting: %d, total wait: %s, max wait: %s",
+			stats.Requests, stats.Delayed, stats.Waiting, stats.TotalWait, stats.MaxWait)
+	}
+	cacheStats := overviewCache.Stats()
+	logger.Infof("Overview cache hits: %d, misses: %d, shared: %d, revalidated: %d",
+		cacheStats.Hits, cacheStats.Misses, cacheStats.Shared, cacheStats.Revalidated)
 }
 
 func getBaseURL(location string) string {
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/bwmarrin/discordgo"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/formatutils"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/resolver"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/scraper"
+	"strings"
+)
+
+// handleNextMatch scrapes the upcoming matches of the given users and answers with an image.
+// Without users the watchlist of the guild is used.
+func handleNextMatch(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := optionMap(i.ApplicationCommandData().Options)
+	location, userIDs := withWatchlistFallback(i.GuildID, stringOption(options, "location"), resolver.SplitTokens(stringOption(options, "users")))
+	baseURL := getBaseURL(location)
+	userIDs, unresolved := resolveUsers(location, userIDs)
+	if len(userIDs) == 0 && len(unresolved) == 0 {
+		respondText(s, i, "No users given and the watchlist is empty. Use `/watch add` to fill it.")
+		return
+	}
+
+	content := "```/nextmatch location: " + location + " users: " + strings.Join(userIDs, " ") + "```"
+
+	// Defer the response, scraping many users easily takes longer than the 3 seconds discord waits for an answer
+	if err := deferResponse(s, i); err != nil {
+		logger.WithError(err).Error("Error deferring the interaction response")
+		return
+	}
+
+	progress := newProgressReporter(s, i, content)
+	olScraper, rememberManagers := newScraper(scraper.WithProgress(progress.Report))
+	defer rememberManagers()
+	nextMatchResults := olScraper.ScrapeNextMatchesContext(ctx, userIDs, baseURL)
+	progress.Stop()
+
+	var nextMatches []parse.UpcomingMatch
+	failed := unresolved
+	for _, nextMatchResult := range nextMatchResults {
+		if nextMatchResult.Err != nil {
+			logger.WithError(nextMatchResult.Err).Errorf("Scraping next match for user %s failed", nextMatchResult.UserID)
+			failed = append(failed, scraper.UserResult{UserID: nextMatchResult.UserID, Err: nextMatchResult.Err})
+			continue
+		}
+		nextMatches = append(nextMatches, nextMatchResult.NextMatch)
+	}
+	if footer := formatutils.FailedUsersFooter(failed); footer != "" {
+		content += "\n" + footer
+	}
+	if len(nextMatches) == 0 {
+		_ = editResponse(s, i, withNotice(content, "No upcoming matches could be loaded."), nil)
+		return
+	}
+
+	imageBuf, imageErr := formatutils.UpcomingMatchesToImage(ctx, nextMatches, imageConfig(resolveTheme(i.GuildID, stringOption(options, "theme"))))
+	if imageErr != nil {
+		logger.WithError(imageErr).Error("Error creating next match image")
+		_ = editResponse(s, i, withNotice(content, "The upcoming matches could not be rendered, please try again later."), nil)
+		return
+	}
+
+	editResponseWithFiles(s, i, content, []*discordgo.File{
+		{
+			Name:   "nextmatch.png",
+			Reader: bytes.NewReader(imageBuf.Bytes()),
+		},
+	})
+}
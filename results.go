@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/bwmarrin/discordgo"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/formatutils"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/resolver"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/scraper"
+	"strings"
+)
+
+// defaultLocation is used if neither the command nor the watchlist specify a location
+const defaultLocation = ".de"
+
+// Output formats of the /results command
+const (
+	outputImage = "image"
+	outputEmbed = "embed"
+	outputText  = "text"
+	outputSVG   = "svg"
+	outputHTML  = "html"
+)
+
+// handleResults scrapes the last match results of the given users and answers with an image, embeds or text.
+// Without users the watchlist of the guild is used.
+func handleResults(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := optionMap(i.ApplicationCommandData().Options)
+	location := stringOption(options, "location")
+	userIDs := resolver.SplitTokens(stringOption(options, "users"))
+
+	location, userIDs = withWatchlistFallback(i.GuildID, location, userIDs)
+	baseURL := getBaseURL(location)
+	userIDs, unresolved := resolveUsers(location, userIDs)
+	if len(userIDs) == 0 && len(unresolved) == 0 {
+		respondText(s, i, "No users given and the watchlist is empty. Use `/watch add` to fill it.")
+		return
+	}
+	logger.Infof("Location: %s", location)
+
+	content := "```/results location: " + location + " users: " + strings.Join(userIDs, " ") + "```"
+
+	// Defer the response, scraping many users easily takes longer than the 3 seconds discord waits for an answer
+	if err := deferResponse(s, i); err != nil {
+		logger.WithError(err).Error("Error deferring the interaction response")
+		return
+	}
+
+	progress := newProgressReporter(s, i, content)
+	olScraper, rememberManagers := newScraper(scraper.WithProgress(progress.Report))
+	defer rememberManagers()
+	userResults := olScraper.ScrapeMatchResultsContext(ctx, userIDs, baseURL)
+	progress.Stop()
+
+	results, failed := formatutils.SplitUserResults(userResults)
+	for _, userResult := range failed {
+		logger.WithError(userResult.Err).Errorf("Scraping match results for user %s failed", userResult.UserID)
+	}
+	failed = append(unresolved, failed...)
+	if footer := formatutils.FailedUsersFooter(failed); footer != "" {
+		content += "\n" + footer
+	}
+	if len(results) == 0 {
+		_ = editResponse(s, i, withNotice(content, "No results could be loaded."), nil)
+		return
+	}
+	results = formatutils.SortResults(results, logger)
+
+	switch stringOption(options, "output") {
+	case outputEmbed:
+		editResponseWithEmbeds(s, i, content, formatutils.MatchResultsToEmbeds(results))
+		return
+	case outputText:
+		// The first block shares its message with the content, unless a long footer leaves too little room for it
+		maxLength := formatutils.MaxMessageLength - len([]rune(content)) - 1
+		if maxLength < formatutils.MaxMessageLength/2 {
+			maxLength = formatutils.MaxMessageLength
+		}
+		editResponseWithText(s, i, content, formatutils.MatchResultsToText(results, maxLength))
+		return
+	}
+
+	config := imageConfig(resolveTheme(i.GuildID, stringOption(options, "theme")))
+	fileName := "SPOILER_results.png"
+	var fileBuf bytes.Buffer
+	var renderErr error
+	switch stringOption(options, "output") {
+	case outputSVG:
+		fileName = "results.svg"
+		fileBuf, renderErr = formatutils.MatchResultsToSVG(results, config)
+	case outputHTML:
+		fileName = "results.html"
+		fileBuf, renderErr = formatutils.MatchResultsToHTML(results, "Results "+location, config)
+	default:
+		fileBuf, renderErr = formatutils.MatchResultsToImage(ctx, results, config)
+	}
+	if renderErr != nil {
+		logger.WithError(renderErr).Error("Error creating image")
+		_ = editResponse(s, i, withNotice(content, "The results could not be rendered, please try again later."), nil)
+		return
+	}
+
+	editResponseWithFiles(s, i, content, []*discordgo.File{
+		{
+			Name:   fileName,
+			Reader: bytes.NewReader(fileBuf.Bytes()),
+		},
+	})
+}
+
+// renderResultsImage renders sorted match results to a PNG image
+func renderResultsImage(ctx context.Context, results []parse.MatchResult, theme formatutils.Theme) (bytes.Buffer, error) {
+	return formatutils.MatchResultsToImage(ctx, results, imageConfig(theme))
+}
+
+// withWatchlistFallback fills a missing location or missing users from the watchlist of the guild.
+// A location that is still missing afterwards is set to defaultLocation.
+func withWatchlistFallback(guildID string, location string, userIDs []string) (string, []string) {
+	if len(userIDs) > 0 && location != "" {
+		return location, userIDs
+	}
+	if guildID != "" {
+		watchlist, err := store.Watchlist(guildID)
+		if err != nil {
+			logger.WithError(err).Errorf("Error loading watchlist for guild %s", guildID)
+		}
+		if len(userIDs) == 0 {
+			userIDs = watchlist.UserIDs
+		}
+		if location == "" {
+			location = watchlist.Location
+		}
+	}
+	if location == "" {
+		location = defaultLocation
+	}
+	return location, userIDs
+}
+
+// guildLocation returns location or, if it is empty, the location of the guild's watchlist or defaultLocation
+func guildLocation(guildID string, location string) string {
+	if location != "" {
+		return location
+	}
+	if guildID != "" {
+		watchlist, err := store.Watchlist(guildID)
+		if err != nil {
+			logger.WithError(err).Errorf("Error loading watchlist for guild %s", guildID)
+		}
+		location = watchlist.Location
+	}
+	if location == "" {
+		location = defaultLocation
+	}
+	return location
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/formatutils"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/scraper"
+	"strconv"
+)
+
+const (
+	// promotionSpots is the number of teams that are promoted at the end of a season
+	promotionSpots = 2
+	// relegationSpots is the number of teams that are relegated at the end of a season
+	relegationSpots = 4
+)
+
+// handleTable renders the complete league table of a user's league
+func handleTable(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	olScraper, rememberManagers := newScraper()
+	defer rememberManagers()
+
+	options := optionMap(i.ApplicationCommandData().Options)
+	location := stringOption(options, "location")
+
+	// Highlight everybody on the watchlist
+	highlighted := []int{}
+	if i.GuildID != "" {
+		watchlist, err := store.Watchlist(i.GuildID)
+		if err != nil {
+			logger.WithError(err).Errorf("Error loading watchlist for guild %s", i.GuildID)
+		}
+		for _, watchedUserID := range watchlist.UserIDs {
+			if userIDInt, convErr := strconv.Atoi(watchedUserID); convErr == nil {
+				highlighted = append(highlighted, userIDInt)
+			}
+		}
+		if location == "" {
+			location = watchlist.Location
+		}
+	}
+	if location == "" {
+		location = defaultLocation
+	}
+
+	// The user may be given by id, mention or name
+	userID := stringOption(options, "user")
+	userIDs, unresolved := resolveUsers(location, []string{userID})
+	if len(unresolved) > 0 {
+		respondText(s, i, fmt.Sprintf("Could not load the league table of %s (%s)", userID, scraper.ErrorReason(unresolved[0].Err)))
+		return
+	}
+	userID = userIDs[0]
+	if userIDInt, err := strconv.Atoi(userID); err == nil {
+		highlighted = append(highlighted, userIDInt)
+	}
+
+	content := "```/table location: " + location + " user: " + userID + "```"
+	if err := deferResponse(s, i); err != nil {
+		logger.WithError(err).Error("Error deferring the interaction response")
+		return
+	}
+
+	rootObject, err := olScraper.ScrapeOverview(ctx, userID, getBaseURL(location))
+	if err != nil {
+		logger.WithError(err).Errorf("Scraping the league table for user %s failed", userID)
+		_ = editResponse(s, i, fmt.Sprintf("%s\nCould not load the league table of %s (%s)", content, userID, scraper.ErrorReason(err)), nil)
+		return
+	}
+
+	imageBuf, err := formatutils.LeagueTableToImage(rootObject.LeagueTables, rootObject.User.League, highlighted, formatutils.TableConfig{
+		ImageConfig:     imageConfig(resolveTheme(i.GuildID, stringOption(options, "theme"))),
+		PromotionSpots:  promotionSpots,
+		RelegationSpots: relegationSpots,
+	})
+	if err != nil {
+		logger.WithError(err).Error("Error creating league table image")
+		_ = editResponse(s, i, withNotice(content, "The league table could not be rendered, please try again later."), nil)
+		return
+	}
+
+	editResponseWithFiles(s, i, content, []*discordgo.File{
+		{
+			Name:   "table.png",
+			Reader: bytes.NewReader(imageBuf.Bytes()),
+		},
+	})
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/formatutils"
+	"strings"
+)
+
+// themeChoices lists all built-in themes for the theme options
+func themeChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, name := range formatutils.ThemeNames() {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  name,
+			Value: name,
+		})
+	}
+	return choices
+}
+
+// resolveTheme returns the theme chosen in the command, else the default theme of the guild, else the default theme
+func resolveTheme(guildID string, name string) formatutils.Theme {
+	if name == "" && guildID != "" {
+		settings, err := store.GuildSettings(guildID)
+		if err != nil {
+			logger.WithError(err).Errorf("Error loading settings for guild %s", guildID)
+		}
+		name = settings.Theme
+	}
+	return formatutils.ThemeByName(name)
+}
+
+// imageConfig returns the image config shared by the results, next match and league table images
+func imageConfig(theme formatutils.Theme) formatutils.ImageConfig {
+	return formatutils.ImageConfig{
+		Badges:      badgeCache,
+		Fonts:       fontChain,
+		FontSize:    14.0,
+		Margin:      28.0,
+		RowHeight:   28.0,
+		BadeWidth:   22.0,
+		BadgeHeight: 22.0,
+		Theme:       theme,
+	}
+}
+
+// handleTheme sets or shows the default image theme of a guild
+func handleTheme(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondText(s, i, "Themes can only be configured in servers")
+		return
+	}
+
+	subCommand := i.ApplicationCommandData().Options[0]
+	options := optionMap(subCommand.Options)
+
+	switch subCommand.Name {
+	case "set":
+		name := stringOption(options, "name")
+		if _, err := store.SetGuildTheme(i.GuildID, name); err != nil {
+			logger.WithError(err).Errorf("Error storing the theme of guild %s", i.GuildID)
+			respondText(s, i, "Something went wrong while storing the theme")
+			return
+		}
+		respondText(s, i, fmt.Sprintf("The default theme is now %s", name))
+	case "show":
+		theme := resolveTheme(i.GuildID, "")
+		respondText(s, i, fmt.Sprintf("The default theme is %s (available: %s)", theme.Name, strings.Join(formatutils.ThemeNames(), ", ")))
+	}
+}
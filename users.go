@@ -0,0 +1,71 @@
+package main
+
+import (
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/parse"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/resolver"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/scraper"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/storage"
+	"sync"
+)
+
+// newScraper returns a scraper with the configured concurrency and the shared overview cache. The managers of
+// all overviews it fetches are collected, the returned function stores them in a single transaction once the
+// request is done.
+func newScraper(opts ...scraper.Option) (scraper.Scraper, func()) {
+	managers := &managerBatch{managers: map[string][]storage.Manager{}}
+	defaults := []scraper.Option{
+		scraper.WithConcurrency(scrapeConcurrency),
+		scraper.WithOverviewHook(managers.add),
+		scraper.WithCache(overviewCache),
+	}
+	return scraper.NewScraper(logger, append(defaults, opts...)...), managers.remember
+}
+
+// managerBatch collects the managers of fetched overviews so they can be looked up by name later.
+// Storing them per request instead of per overview keeps a fan-out to a single write transaction.
+type managerBatch struct {
+	mu       sync.Mutex
+	managers map[string][]storage.Manager
+}
+
+// add implements scraper.OverviewHook
+func (b *managerBatch) add(baseURL string, rootObject parse.Root) {
+	var managers []storage.Manager
+	addManager := func(userID int, username string, teamName string) {
+		if userID != 0 {
+			managers = append(managers, storage.Manager{UserID: userID, Username: username, TeamName: teamName})
+		}
+	}
+
+	addManager(rootObject.User.UID, rootObject.User.Username, "")
+	for _, match := range []parse.Match{rootObject.MatchData.LastMatch, rootObject.MatchData.NextMatch} {
+		addManager(match.UserA.UID, match.UserA.Username, match.UserA.TeamName)
+		addManager(match.UserB.UID, match.UserB.Username, match.UserB.TeamName)
+	}
+	for _, team := range rootObject.LeagueTables {
+		addManager(team.UserID, "", team.TeamName)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.managers[baseURL] = append(b.managers[baseURL], managers...)
+}
+
+// remember stores the collected managers and empties the batch
+func (b *managerBatch) remember() {
+	b.mu.Lock()
+	batch := b.managers
+	b.managers = map[string][]storage.Manager{}
+	b.mu.Unlock()
+
+	for baseURL, managers := range batch {
+		if err := store.RememberManagers(baseURL, managers); err != nil {
+			logger.WithError(err).Warn("Remembering managers failed")
+		}
+	}
+}
+
+// resolveUsers turns user tokens into onlineliga user ids of location, see resolver.Resolver.Resolve
+func resolveUsers(location string, tokens []string) ([]string, []scraper.UserResult) {
+	return resolver.NewResolver(store, logger, getBaseURL).Resolve(location, tokens)
+}
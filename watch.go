@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/formatutils"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/resolver"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/scraper"
+	"github.com/tripleawwy/onlineliga_discord_bot/internal/storage"
+	"strings"
+)
+
+// handleWatch manages the watchlist of a guild with the add, remove and list sub commands
+func handleWatch(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondText(s, i, "Watchlists are only available in servers")
+		return
+	}
+
+	subCommand := i.ApplicationCommandData().Options[0]
+	options := optionMap(subCommand.Options)
+	userIDs := resolver.SplitTokens(stringOption(options, "users"))
+
+	var watchlist storage.Watchlist
+	var unresolved []scraper.UserResult
+	var err error
+	switch subCommand.Name {
+	case "add":
+		// Names and mentions are stored as user ids
+		location := guildLocation(i.GuildID, stringOption(options, "location"))
+		userIDs, unresolved = resolveUsers(location, userIDs)
+		watchlist, err = store.AddToWatchlist(i.GuildID, location, userIDs)
+	case "remove":
+		// Names and mentions are resolved like in add, so they match the stored user ids
+		userIDs, unresolved = resolveUsers(guildLocation(i.GuildID, ""), userIDs)
+		watchlist, err = store.RemoveFromWatchlist(i.GuildID, userIDs)
+	case "list":
+		watchlist, err = store.Watchlist(i.GuildID)
+	}
+	var mismatchErr *storage.LocationMismatchError
+	if errors.As(err, &mismatchErr) {
+		respondText(s, i, fmt.Sprintf("The watchlist holds users of %s, remove them before adding users of another location", mismatchErr.Location))
+		return
+	}
+	if err != nil {
+		logger.WithError(err).Errorf("Error handling /watch %s for guild %s", subCommand.Name, i.GuildID)
+		respondText(s, i, "Something went wrong while accessing the watchlist")
+		return
+	}
+
+	content := formatWatchlist(watchlist)
+	if footer := formatutils.FailedUsersFooter(unresolved); footer != "" {
+		content += "\n" + footer
+	}
+	respondText(s, i, content)
+}
+
+// formatWatchlist renders a watchlist as a short message
+func formatWatchlist(watchlist storage.Watchlist) string {
+	if len(watchlist.UserIDs) == 0 {
+		return "The watchlist is empty"
+	}
+	location := watchlist.Location
+	if location == "" {
+		location = defaultLocation
+	}
+	return fmt.Sprintf("```Watchlist (%s, %d users): %s```", location, len(watchlist.UserIDs), strings.Join(watchlist.UserIDs, " "))
+}